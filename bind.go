@@ -0,0 +1,233 @@
+// Copyright 2020 orivil.com. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found at https://mit-license.org.
+
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+)
+
+// PathTag is the tag of the fields which are set by the path parameters, such as `path:"id"`
+const PathTag = "path"
+
+// the sources of the request values
+const (
+	SourcePath      = "path"
+	SourceQuery     = "query"
+	SourceForm      = "form"
+	SourceMultipart = "multipart"
+	SourceJSON      = "json"
+)
+
+// PathValuer reads the path parameters of the requests, it could be implemented for any router
+type PathValuer interface {
+	PathValue(r *http.Request, name string) string
+}
+
+type PathValuerFunc func(r *http.Request, name string) string
+
+func (f PathValuerFunc) PathValue(r *http.Request, name string) string {
+	return f(r, name)
+}
+
+// StdPathValuer reads the path parameters by http.Request.PathValue of the net/http router
+var StdPathValuer PathValuer = PathValuerFunc(func(r *http.Request, name string) string {
+	return r.PathValue(name)
+})
+
+// BindError is returned if the request could not be read or decoded, the failures of validating are
+// returned as *ValidationError or ValidationErrors
+type BindError struct {
+	Source string
+	Err    error
+}
+
+func (e *BindError) Error() string {
+	return fmt.Sprintf("schema bind [%s] got error: %v", e.Source, e.Err)
+}
+
+func (e *BindError) Unwrap() error {
+	return e.Err
+}
+
+// Binder binds the requests to structs and validates them
+type Binder struct {
+	// PathValuer reads the path parameters, the path parameters are not bound if it is nil
+	PathValuer PathValuer
+	// MaxMemory is the max memory of parsing the multipart forms, the rest of the files are stored
+	// in temporary files, it is also the max size of the JSON bodies, DefaultMaxMemory is used if it is
+	// not positive
+	MaxMemory int64
+	// Options are used by every validating, AsError is always used
+	Options []ValidOption
+	// UrlCodec decodes the nested keys of the query and the urlencoded forms, the nested structs are
+	// decoded from the embedded query strings if it is nil
+	UrlCodec *UrlCodec
+}
+
+// DefaultMaxMemory is the default Binder.MaxMemory
+const DefaultMaxMemory = 32 << 20
+
+func NewBinder(pv PathValuer) *Binder {
+	return &Binder{PathValuer: pv, MaxMemory: DefaultMaxMemory}
+}
+
+func (b *Binder) maxMemory() int64 {
+	if b.MaxMemory <= 0 {
+		return DefaultMaxMemory
+	}
+	return b.MaxMemory
+}
+
+// Bind fills v by the request and returns the first failure, the values are read from the query for the
+// GET, HEAD and DELETE requests, otherwise from the body by the Content-Type, which could be
+// "application/json", "application/x-www-form-urlencoded" or "multipart/form-data", the path parameters
+// are always bound, v should be pointer to struct
+func (b *Binder) Bind(r *http.Request, v interface{}) error {
+	return b.bind(r, v, false)
+}
+
+// BindAll is the same as Bind but returns all the failures as ValidationErrors
+func (b *Binder) BindAll(r *http.Request, v interface{}) error {
+	return b.bind(r, v, true)
+}
+
+func (b *Binder) bind(r *http.Request, v interface{}, all bool) error {
+	p, err := b.decode(r, v)
+	if err != nil {
+		return err
+	}
+	if b.PathValuer != nil {
+		err = b.bindPath(r, v, p)
+		if err != nil {
+			return &BindError{Source: SourcePath, Err: err}
+		}
+	}
+	// the schema is built after decoding since the schemas of maps and interfaces depend on the values
+	s, err := SchemaOf(v)
+	if err != nil {
+		return err
+	}
+	opts := append([]ValidOption{WithPresence(p)}, b.Options...)
+	opts = append(opts, AsError())
+	if all {
+		_, err = s.ValidAll(v, opts...)
+	} else {
+		_, err = s.Valid(v, opts...)
+	}
+	return err
+}
+
+func (b *Binder) unmarshalUrl(values url.Values, v interface{}) (Presence, error) {
+	if b.UrlCodec != nil {
+		return b.UrlCodec.UnmarshalPresence(values, v)
+	}
+	return UnmarshalUrlPresence(values, v)
+}
+
+// decode fills v by the query or the body and returns the presence of the values
+func (b *Binder) decode(r *http.Request, v interface{}) (Presence, error) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete:
+		p, err := b.unmarshalUrl(r.URL.Query(), v)
+		if err != nil {
+			return nil, &BindError{Source: SourceQuery, Err: err}
+		}
+		return p, nil
+	}
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		return make(Presence), nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, &BindError{Source: SourceForm, Err: err}
+	}
+	switch mediaType {
+	case "application/json":
+		p, err := decodeJSONBody(r, v, b.maxMemory())
+		if err != nil {
+			return nil, &BindError{Source: SourceJSON, Err: err}
+		}
+		return p, nil
+	case "application/x-www-form-urlencoded":
+		err = r.ParseForm()
+		if err == nil {
+			var p Presence
+			p, err = b.unmarshalUrl(r.PostForm, v)
+			if err == nil {
+				return p, nil
+			}
+		}
+		return nil, &BindError{Source: SourceForm, Err: err}
+	case "multipart/form-data":
+		err = r.ParseMultipartForm(b.maxMemory())
+		if err == nil {
+			var p Presence
+			p, err = UnmarshalMultipartPresence(r.MultipartForm, v)
+			if err == nil {
+				return p, nil
+			}
+		}
+		return nil, &BindError{Source: SourceMultipart, Err: err}
+	default:
+		return nil, &BindError{Source: SourceForm, Err: fmt.Errorf("unsupported media type %s", mediaType)}
+	}
+}
+
+// decodeJSONBody reads at most maxSize bytes of the body, a larger body is an error
+func decodeJSONBody(r *http.Request, v interface{}, maxSize int64) (Presence, error) {
+	data, err := ioutil.ReadAll(http.MaxBytesReader(nil, r.Body, maxSize))
+	if err != nil {
+		return nil, err
+	}
+	p := make(Presence)
+	if len(data) == 0 {
+		return p, nil
+	}
+	var tree interface{}
+	err = json.Unmarshal(data, &tree)
+	if err != nil {
+		return nil, err
+	}
+	addPresence(p, "", tree)
+	return p, json.Unmarshal(data, v)
+}
+
+// bindPath sets the fields tagged by PathTag, the empty path parameters are ignored
+func (b *Binder) bindPath(r *http.Request, v interface{}, p Presence) error {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("only support struct or pointer of struct, got %s", rv.Kind())
+	}
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		ft := rt.Field(i)
+		name := ft.Tag.Get(PathTag)
+		fv := rv.Field(i)
+		if name == "" || !fv.CanSet() {
+			continue
+		}
+		value := b.PathValuer.PathValue(r, name)
+		if value == "" {
+			continue
+		}
+		property := getFieldName(ft.Tag)
+		if property == "" {
+			property = ft.Name
+		}
+		p.add(property)
+		err := getUrlSetter(ft.Type)([]string{value}, fv, property, p)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
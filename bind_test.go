@@ -0,0 +1,162 @@
+// Copyright 2020 orivil.com. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found at https://mit-license.org.
+
+package schema_test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/orivil/schema"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type bindUser struct {
+	ID     int             `json:"id" path:"id" schema:"required"`
+	Name   string          `json:"name" schema:"required;maxLen:8"`
+	Age    *int            `json:"age" schema:"required:present"`
+	Avatar schema.FileData `json:"avatar"`
+}
+
+func TestBinder(t *testing.T) {
+	binder := schema.NewBinder(schema.StdPathValuer)
+	type testCase struct {
+		method      string
+		target      string
+		contentType string
+		body        string
+		need        string
+		rule        string
+		source      string
+	}
+	buf := &bytes.Buffer{}
+	w := multipart.NewWriter(buf)
+	_ = w.WriteField("name", "Nina")
+	_ = w.WriteField("age", "18")
+	fw, _ := w.CreateFormFile("avatar", "avatar.png")
+	_, _ = fw.Write([]byte("png"))
+	_ = w.Close()
+	testCases := []testCase{
+		{
+			method: http.MethodGet,
+			target: "/users/1?name=Nina&age=0",
+			need:   `{"id":1,"name":"Nina","age":0,"avatar":null}`,
+		},
+		{method: http.MethodGet, target: "/users/1?name=Nina", rule: schema.OptionsRequired},
+		{method: http.MethodGet, target: "/users/1?name=Nina&age=a", source: schema.SourceQuery},
+		{
+			method:      http.MethodPost,
+			target:      "/users/2",
+			contentType: "application/json",
+			body:        `{"name":"Nina","age":18}`,
+			need:        `{"id":2,"name":"Nina","age":18,"avatar":null}`,
+		},
+		{
+			method:      http.MethodPost,
+			target:      "/users/2",
+			contentType: "application/json",
+			body:        `{"name":"Nina Nina Nina","age":18}`,
+			rule:        schema.MaxLen,
+		},
+		{
+			method:      http.MethodPost,
+			target:      "/users/2",
+			contentType: "application/json",
+			body:        `{"name":`,
+			source:      schema.SourceJSON,
+		},
+		{
+			method:      http.MethodPut,
+			target:      "/users/3",
+			contentType: "application/x-www-form-urlencoded; charset=utf-8",
+			body:        "name=Nina&age=18",
+			need:        `{"id":3,"name":"Nina","age":18,"avatar":null}`,
+		},
+		{
+			method:      http.MethodPost,
+			target:      "/users/4",
+			contentType: w.FormDataContentType(),
+			body:        buf.String(),
+			need:        `{"id":4,"name":"Nina","age":18,"avatar":"cG5n"}`,
+		},
+		{method: http.MethodPost, target: "/users/5", contentType: "text/plain", body: "Nina", source: schema.SourceForm},
+	}
+	for i, tc := range testCases {
+		var got *bindUser
+		var err error
+		mux := http.NewServeMux()
+		mux.HandleFunc("/users/{id}", func(rw http.ResponseWriter, r *http.Request) {
+			got = &bindUser{}
+			err = binder.Bind(r, got)
+		})
+		r := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+		if tc.contentType != "" {
+			r.Header.Set("Content-Type", tc.contentType)
+		}
+		mux.ServeHTTP(httptest.NewRecorder(), r)
+		var ve *schema.ValidationError
+		var be *schema.BindError
+		switch {
+		case tc.rule != "":
+			if !errors.As(err, &ve) || ve.Rule != tc.rule {
+				t.Errorf("case %d need failure of %s, got: %v", i, tc.rule, err)
+			}
+		case tc.source != "":
+			if !errors.As(err, &be) || be.Source != tc.source {
+				t.Errorf("case %d need bind error of %s, got: %v", i, tc.source, err)
+			}
+		case err != nil:
+			t.Errorf("case %d got error: %v", i, err)
+		case jsonCompact(got) != tc.need:
+			t.Errorf("case %d need: %s\ngot: %s", i, tc.need, jsonCompact(got))
+		}
+	}
+}
+
+func TestBinderMaxMemory(t *testing.T) {
+	binder := schema.NewBinder(schema.StdPathValuer)
+	binder.MaxMemory = 16
+	r := httptest.NewRequest(http.MethodPost, "/users/1", strings.NewReader(`{"name":"Nina","age":18}`))
+	r.Header.Set("Content-Type", "application/json")
+	err := binder.Bind(r, &bindUser{})
+	var be *schema.BindError
+	if !errors.As(err, &be) || be.Source != schema.SourceJSON {
+		t.Fatalf("need bind error of %s, got: %v", schema.SourceJSON, err)
+	}
+}
+
+func TestZeroBinder(t *testing.T) {
+	// the zero MaxMemory is the default
+	binder := &schema.Binder{PathValuer: schema.StdPathValuer}
+	r := httptest.NewRequest(http.MethodPost, "/users/1", strings.NewReader(`{"id":1,"name":"Nina","age":18}`))
+	r.Header.Set("Content-Type", "application/json")
+	got := &bindUser{}
+	err := binder.Bind(r, got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "Nina" {
+		t.Fatalf("need name Nina, got: %s", jsonCompact(got))
+	}
+}
+
+func TestBinderPathValuer(t *testing.T) {
+	// a third-party router could keep the path parameters in the context
+	binder := schema.NewBinder(schema.PathValuerFunc(func(r *http.Request, name string) string {
+		return strings.TrimPrefix(r.URL.Path, "/users/")
+	}))
+	r := httptest.NewRequest(http.MethodDelete, "/users/6?age=1", nil)
+	got := &bindUser{}
+	err := binder.BindAll(r, got)
+	var es schema.ValidationErrors
+	if !errors.As(err, &es) || len(es) != 1 || es[0].Field != "name" {
+		t.Fatalf("need failure of name, got: %v", err)
+	}
+	if got.ID != 6 {
+		t.Fatalf("need id 6, got: %d", got.ID)
+	}
+}
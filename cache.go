@@ -0,0 +1,65 @@
+// Copyright 2020 orivil.com. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found at https://mit-license.org.
+
+package schema
+
+import (
+	"reflect"
+	"sync"
+)
+
+var schemaCache sync.Map
+
+// SchemaOf returns the schema of the type of v, the schema is built once from the zero value of the type
+// and shared by every caller, so it is read-only and the With methods must not be called on it, a schema
+// to be modified should be built by NewSchema. The schemas of the types which depend on the values, such
+// as the types containing maps, interfaces or Decoder implementations, are built on every call as
+// NewSchema does
+func SchemaOf(v interface{}) (*Schema, error) {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return nil, nil
+	}
+	if s, ok := schemaCache.Load(t); ok {
+		return s.(*Schema), nil
+	}
+	if !isStaticType(t, make(map[reflect.Type]struct{})) {
+		return NewSchema(v)
+	}
+	s, err := NewSchema(reflect.New(indirectType(t)).Interface())
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := schemaCache.LoadOrStore(t, s)
+	return actual.(*Schema), nil
+}
+
+// isStaticType reports whether the schema of t does not depend on the values
+func isStaticType(t reflect.Type, existStructs map[reflect.Type]struct{}) bool {
+	if t.Implements(decoderType) || t.Kind() != reflect.Ptr && reflect.PtrTo(t).Implements(decoderType) {
+		return false
+	}
+	t = indirectType(t)
+	if GoToJSONType(t) == File {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Interface, reflect.Map:
+		return false
+	case reflect.Slice, reflect.Array:
+		return isStaticType(t.Elem(), existStructs)
+	case reflect.Struct:
+		if _, ok := existStructs[t]; ok {
+			return true
+		}
+		existStructs[t] = struct{}{}
+		for i := 0; i < t.NumField(); i++ {
+			ft := t.Field(i)
+			if !isFieldIgnored(ft.Tag) && !isStaticType(ft.Type, existStructs) {
+				return false
+			}
+		}
+	}
+	return true
+}
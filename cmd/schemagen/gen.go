@@ -0,0 +1,206 @@
+// Copyright 2020 orivil.com. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found at https://mit-license.org.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/orivil/schema"
+	"strconv"
+	"strings"
+)
+
+type generator struct {
+	pkg       *pkgInfo
+	namespace string
+	buf       bytes.Buffer
+	// vars counts the schema variables of a Schema method
+	vars int
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func (g *generator) generate() ([]byte, error) {
+	g.printf("// Code generated by schemagen. DO NOT EDIT.\n\n")
+	g.printf("package %s\n\n", g.pkg.name)
+	g.printf("import \"github.com/orivil/schema\"\n")
+	for _, name := range g.pkg.annotated {
+		si := g.pkg.structs[name]
+		g.generateSchema(si)
+		g.generateValidate(si)
+		g.generateIsZero(si)
+	}
+	return g.buf.Bytes(), nil
+}
+
+func (g *generator) modelRef(name string) string {
+	if g.namespace == "" {
+		return name
+	}
+	return g.namespace + "." + name
+}
+
+// generateSchema generates the Schema method which builds the same schema as schema.NewSchema, the
+// first schema of a struct is inline and the others are Ref schemas
+func (g *generator) generateSchema(si *structInfo) {
+	g.printf("\nvar _%s_schema = new(%s).Schema()\n", si.name, si.name)
+	g.printf("\n// Schema implements schema.Decoder\n")
+	g.printf("func (x *%s) Schema() *schema.Schema {\n", si.name)
+	g.printf("ms := make(schema.Models)\n")
+	g.vars = 0
+	root := g.structSchema(si, make(map[string]bool))
+	g.printf("return %s.WithModels(ms)\n}\n", root)
+}
+
+// structSchema generates the schema of the struct and returns the variable name
+func (g *generator) structSchema(si *structInfo, existStructs map[string]bool) string {
+	v := g.newVar()
+	if existStructs[si.name] {
+		g.printf("%s := &schema.Schema{Ref: %q}\n", v, g.modelRef(si.name))
+		return v
+	}
+	existStructs[si.name] = true
+	g.printf("%s := &schema.Schema{Model: %q, Namespace: %q, Type: schema.Object}\n", v, si.name, g.namespace)
+	g.printf("ms[%q] = %s\n", g.modelRef(si.name), v)
+	g.printf("%s.Properties = schema.Properties{}\n", v)
+	for _, f := range si.fields {
+		if f.ignored {
+			continue
+		}
+		fv := g.typeSchema(f.typ, existStructs)
+		g.printf("%s.Properties = append(%s.Properties, schema.MustProperty(%s, %q, %s))\n", v, v, fv, f.property, quote(f.tag))
+	}
+	return v
+}
+
+func (g *generator) typeSchema(t *fieldType, existStructs map[string]bool) string {
+	if t.slice {
+		v := g.newVar()
+		items := g.elemSchema(t.name, existStructs)
+		g.printf("%s := &schema.Schema{Type: schema.Array, Items: %s}\n", v, items)
+		return v
+	}
+	return g.elemSchema(t.name, existStructs)
+}
+
+func (g *generator) elemSchema(name string, existStructs map[string]bool) string {
+	if kind, ok := builtinKinds[name]; ok {
+		v := g.newVar()
+		g.printf("%s := &schema.Schema{Type: schema.%s}\n", v, kindName(kind))
+		return v
+	}
+	return g.structSchema(g.pkg.structs[name], existStructs)
+}
+
+// quote returns the raw string literal of the tag if possible
+func quote(tag string) string {
+	if strings.Contains(tag, "`") {
+		return strconv.Quote(tag)
+	}
+	return "`" + tag + "`"
+}
+
+func (g *generator) newVar() string {
+	g.vars++
+	return "s" + strconv.Itoa(g.vars)
+}
+
+// kindName returns the constant name of the kind, such as "Bool" for "Boolean"
+func kindName(kind schema.JsonKind) string {
+	if kind == schema.Bool {
+		return "Bool"
+	}
+	return string(kind)
+}
+
+func (g *generator) generateValidate(si *structInfo) {
+	g.printf("\n// Validate validates x without reflection, it returns the same failure as Schema.Valid with the\n")
+	g.printf("// AsError option\n")
+	g.printf("func (x *%s) Validate() error {\n", si.name)
+	g.printf("v := x\nif v == nil {\nv = new(%s)\n}\n", si.name)
+	g.printf("s := _%s_schema\n", si.name)
+	g.printf("c := schema.NewChecker(s)\n")
+	g.printf("if ps, restore := c.Object(s, \"\", *v, true, !v.isZeroSchema()); ps != nil {\n")
+	g.printf("v.validateSchema(c, ps, \"\")\nif x != nil {\nc.ValidSchema(ps, \"\", x)\n}\nrestore()\n}\n")
+	g.printf("return c.Err()\n}\n")
+
+	g.printf("\nfunc (x *%s) validateSchema(c *schema.Checker, s *schema.Schema, field string) {\n", si.name)
+	idx := 0
+	for _, f := range si.fields {
+		if f.ignored {
+			continue
+		}
+		g.printf("{\n")
+		g.printf("name := c.Field(field, %q)\n", f.property)
+		g.validateField(f.typ, fmt.Sprintf("s.Properties[%d]", idx), "x."+f.goName)
+		g.printf("if c.Stopped() {\nreturn\n}\n")
+		g.printf("}\n")
+		idx++
+	}
+	g.printf("}\n")
+}
+
+// validateField generates the validation of the field value v, the path of the field is in the "name"
+// variable
+func (g *generator) validateField(t *fieldType, s, v string) {
+	if !t.slice {
+		g.validateElem(t.name, t.pointer, s, "name", v)
+		return
+	}
+	g.printf("var actual interface{}\nif %s != nil {\nactual = %s\n}\n", v, v)
+	g.printf("if items := %s; c.Array(items, name, actual, len(%s), false, %s != nil) {\n", s, v, v)
+	g.printf("for i, item := range %s {\n", v)
+	g.validateElem(t.name, t.elemPointer, "items.Items", "c.Item(name, i)", "item")
+	g.printf("if c.Stopped() {\nreturn\n}\n}\n}\n")
+}
+
+func (g *generator) validateElem(name string, pointer bool, s, field, v string) {
+	_, builtin := builtinKinds[name]
+	switch {
+	case builtin && !pointer:
+		g.printf("c.Value(%s, %s, %s, false, %s)\n", s, field, v, nonzero(name, v))
+	case builtin:
+		g.printf("{\nvar actual interface{}\nif %s != nil {\nactual = *%s\n}\n", v, v)
+		g.printf("c.Value(%s, %s, actual, true, %s != nil && %s)\n}\n", s, field, v, nonzero(name, "*"+v))
+	case !pointer:
+		g.printf("if ps, restore := c.Object(%s, %s, %s, false, !%s.isZeroSchema()); ps != nil {\n", s, field, v, v)
+		g.printf("%s.validateSchema(c, ps, %s)\nc.ValidSchema(ps, %s, &%s)\nrestore()\n}\n", v, field, field, v)
+	default:
+		g.printf("{\nvar actual interface{}\nif %s != nil {\nactual = *%s\n}\n", v, v)
+		g.printf("if ps, restore := c.Object(%s, %s, actual, true, %s != nil && !%s.isZeroSchema()); ps != nil {\n", s, field, v, v)
+		g.printf("v := %s\nif v == nil {\nv = new(%s)\n}\n", v, name)
+		g.printf("v.validateSchema(c, ps, %s)\nif %s != nil {\nc.ValidSchema(ps, %s, v)\n}\nrestore()\n}\n}\n", field, v, field)
+	}
+}
+
+func nonzero(name, v string) string {
+	switch builtinKinds[name] {
+	case schema.String:
+		return v + ` != ""`
+	case schema.Bool:
+		return v
+	default:
+		return v + " != 0"
+	}
+}
+
+func (g *generator) generateIsZero(si *structInfo) {
+	annotated := make(map[string]bool, len(g.pkg.structs))
+	for name := range g.pkg.structs {
+		annotated[name] = true
+	}
+	checks := make([]string, 0, len(si.fields))
+	for _, f := range si.fields {
+		check, _ := zeroCheck(f.expr, annotated, "x."+f.goName)
+		checks = append(checks, check)
+	}
+	if len(checks) == 0 {
+		checks = append(checks, "true")
+	}
+	g.printf("\nfunc (x *%s) isZeroSchema() bool {\n", si.name)
+	g.printf("return %s\n}\n", strings.Join(checks, " &&\n"))
+}
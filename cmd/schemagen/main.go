@@ -0,0 +1,83 @@
+// Copyright 2020 orivil.com. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found at https://mit-license.org.
+
+// Command schemagen generates the reflection-free validators of the structs annotated by the
+// "//schema:generate" comment. For every annotated type T, it generates the "Schema() *schema.Schema"
+// method which implements schema.Decoder, and the "Validate() error" method which gives the same failure
+// as Schema.Valid with the schema.AsError option.
+//
+// The supported field types are the strings, booleans, numbers, the annotated structs of the same
+// package, and the pointers and slices of them.
+//
+// Usage:
+//
+//	//go:generate go run github.com/orivil/schema/cmd/schemagen
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("schemagen: ")
+	output := flag.String("output", "schema_gen.go", "output file name")
+	namespace := flag.String("namespace", "", "namespace of the models, default is the import path of the package")
+	flag.Parse()
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+	if *namespace == "" {
+		out, err := exec.Command("go", "list", "-f", "{{.ImportPath}}", dir).Output()
+		if err != nil {
+			log.Fatalf("get import path: %v", err)
+		}
+		*namespace = strings.TrimSpace(string(out))
+	}
+	src, err := generate(dir, *namespace, *output)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, *output), src, 0644)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// generate returns the formatted source of the annotated structs in dir, the output file is not parsed
+func generate(dir, namespace, output string) ([]byte, error) {
+	pkg, err := parsePackage(dir, output)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkg.annotated) == 0 {
+		return nil, fmt.Errorf("no annotated struct in %s", dir)
+	}
+	g := &generator{pkg: pkg, namespace: namespace}
+	src, err := g.generate()
+	if err != nil {
+		return nil, err
+	}
+	formatted, err := format.Source(src)
+	if err != nil {
+		return nil, fmt.Errorf("format the generated source: %v\n%s", err, src)
+	}
+	return formatted, nil
+}
+
+func init() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: schemagen [-output file] [-namespace path] [dir]\n")
+		flag.PrintDefaults()
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2020 orivil.com. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found at https://mit-license.org.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerateCorpus(t *testing.T) {
+	dir := filepath.Join("..", "..", "internal", "corpus")
+	got, err := generate(dir, "github.com/orivil/schema/internal/corpus", "schema_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	need, err := ioutil.ReadFile(filepath.Join(dir, "schema_gen.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(need) {
+		t.Fatal("the generated corpus is out of date, run go generate ./internal/corpus")
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"unsupported": "package p\n\n//schema:generate\ntype T struct {\n\tM map[string]int `json:\"m\"`\n}\n",
+		"annotated":   "package p\n\ntype S struct{}\n\n//schema:generate\ntype T struct {\n\tS S `json:\"s\"`\n}\n",
+		"tag":         "package p\n\n//schema:generate\ntype T struct {\n\tN int `json:\"n\" schema:\"maxNum:a\"`\n}\n",
+		"sibling":     "package p\n\n//schema:generate\ntype T struct {\n\tA int `json:\"a\"`\n\tB int `json:\"b\" schema:\"requiredWith:a\"`\n}\n",
+		"anonymous":   "package p\n\ntype S struct{}\n\n//schema:generate\ntype T struct {\n\tS\n}\n",
+		"none":        "package p\n\ntype T struct{}\n",
+	}
+	for name, src := range files {
+		sub := filepath.Join(dir, name)
+		err := os.Mkdir(sub, 0755)
+		if err == nil {
+			err = ioutil.WriteFile(filepath.Join(sub, "p.go"), []byte(src), 0644)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = generate(sub, "p", "schema_gen.go")
+		if err == nil {
+			t.Errorf("%s: need error", name)
+		}
+	}
+}
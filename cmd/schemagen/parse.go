@@ -0,0 +1,280 @@
+// Copyright 2020 orivil.com. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found at https://mit-license.org.
+
+package main
+
+import (
+	"fmt"
+	"github.com/orivil/schema"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const annotation = "//schema:generate"
+
+// builtin kinds of the supported field types
+var builtinKinds = map[string]schema.JsonKind{
+	"string":  schema.String,
+	"bool":    schema.Bool,
+	"int":     schema.Number,
+	"int8":    schema.Number,
+	"int16":   schema.Number,
+	"int32":   schema.Number,
+	"int64":   schema.Number,
+	"uint":    schema.Number,
+	"uint8":   schema.Number,
+	"uint16":  schema.Number,
+	"uint32":  schema.Number,
+	"uint64":  schema.Number,
+	"float32": schema.Number,
+	"float64": schema.Number,
+	"byte":    schema.Number,
+	"rune":    schema.Number,
+}
+
+type pkgInfo struct {
+	name string
+	// annotated holds the names of the annotated structs in source order
+	annotated []string
+	structs   map[string]*structInfo
+}
+
+type structInfo struct {
+	name   string
+	fields []*fieldInfo
+}
+
+type fieldInfo struct {
+	goName string
+	// property is the name of the json tag or the field name
+	property string
+	tag      string
+	// ignored is true for the fields with the `json:"-"` tag, they are only checked by the zero value
+	ignored bool
+	typ     *fieldType
+	expr    ast.Expr
+}
+
+// fieldType is a supported field type, such as "string", "*int", "[]*T"
+type fieldType struct {
+	pointer bool
+	slice   bool
+	// elemPointer is true for the slices of pointers
+	elemPointer bool
+	// name is the builtin type name or the annotated struct name
+	name string
+}
+
+func parsePackage(dir, output string) (*pkgInfo, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go") && info.Name() != filepath.Base(output)
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("need one package in %s, got %d", dir, len(pkgs))
+	}
+	var files []*ast.File
+	info := &pkgInfo{structs: make(map[string]*structInfo)}
+	for name, pkg := range pkgs {
+		info.name = name
+		var names []string
+		for name := range pkg.Files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			files = append(files, pkg.Files[name])
+		}
+	}
+	specs := make(map[string]*ast.StructType)
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts := spec.(*ast.TypeSpec)
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok {
+					continue
+				}
+				specs[ts.Name.Name] = st
+				doc := ts.Doc
+				if doc == nil && len(gd.Specs) == 1 {
+					doc = gd.Doc
+				}
+				if isAnnotated(doc) {
+					info.annotated = append(info.annotated, ts.Name.Name)
+				}
+			}
+		}
+	}
+	annotated := make(map[string]bool, len(info.annotated))
+	for _, name := range info.annotated {
+		annotated[name] = true
+	}
+	for _, name := range info.annotated {
+		si, err := parseStruct(fset, name, specs[name], annotated)
+		if err != nil {
+			return nil, err
+		}
+		info.structs[name] = si
+	}
+	return info, nil
+}
+
+func isAnnotated(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if strings.TrimSpace(c.Text) == annotation {
+			return true
+		}
+	}
+	return false
+}
+
+func parseStruct(fset *token.FileSet, name string, st *ast.StructType, annotated map[string]bool) (*structInfo, error) {
+	si := &structInfo{name: name}
+	for _, field := range st.Fields.List {
+		pos := fset.Position(field.Pos())
+		if len(field.Names) == 0 {
+			return nil, fmt.Errorf("%s: anonymous field of %s is not supported", pos, name)
+		}
+		var tag string
+		if field.Tag != nil {
+			var err error
+			tag, err = strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", pos, err)
+			}
+		}
+		st := reflect.StructTag(tag)
+		ignored := st.Get("json") == "-"
+		for _, ident := range field.Names {
+			fi := &fieldInfo{goName: ident.Name, tag: tag, ignored: ignored, expr: field.Type}
+			if !ignored {
+				fi.property = st.Get("json")
+				if idx := strings.Index(fi.property, ","); idx != -1 {
+					fi.property = fi.property[:idx]
+				}
+				if fi.property == "" {
+					fi.property = ident.Name
+				}
+				typ, err := parseFieldType(field.Type, annotated)
+				if err != nil {
+					return nil, fmt.Errorf("%s: field %s.%s: %v", pos, name, ident.Name, err)
+				}
+				fi.typ = typ
+				// the tags are checked here so that the generated code never panics
+				ps := &schema.Schema{Type: typ.kind()}
+				err = ps.WithTagOptions(st)
+				if err != nil {
+					return nil, fmt.Errorf("%s: field %s.%s: %v", pos, name, ident.Name, err)
+				}
+				if hasSiblingRules(ps.Validations) {
+					return nil, fmt.Errorf("%s: field %s.%s: the rules of the sibling fields are not supported", pos, name, ident.Name)
+				}
+			} else if _, err := zeroCheck(field.Type, annotated, "x"); err != nil {
+				return nil, fmt.Errorf("%s: field %s.%s: %v", pos, name, ident.Name, err)
+			}
+			si.fields = append(si.fields, fi)
+		}
+	}
+	return si, nil
+}
+
+// hasSiblingRules reports whether the rules depend on the sibling fields, which are not known by the
+// generated code
+func hasSiblingRules(vs *schema.Validations) bool {
+	return vs != nil && (vs.RequiredIf != nil || vs.RequiredWith != nil || vs.RequiredWithout != nil ||
+		vs.EqField != "" || vs.NeField != "" || vs.GtField != "" || vs.GteField != "" || vs.LtField != "" ||
+		vs.LteField != "")
+}
+
+func (t *fieldType) kind() schema.JsonKind {
+	if t.slice {
+		return schema.Array
+	}
+	if kind, ok := builtinKinds[t.name]; ok {
+		return kind
+	}
+	return schema.Object
+}
+
+func parseFieldType(expr ast.Expr, annotated map[string]bool) (*fieldType, error) {
+	t := &fieldType{}
+	if star, ok := expr.(*ast.StarExpr); ok {
+		t.pointer = true
+		expr = star.X
+	} else if array, ok := expr.(*ast.ArrayType); ok && array.Len == nil {
+		t.slice = true
+		expr = array.Elt
+		if star, ok := expr.(*ast.StarExpr); ok {
+			t.elemPointer = true
+			expr = star.X
+		}
+	}
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return nil, fmt.Errorf("unsupported type %s", exprString(expr))
+	}
+	t.name = ident.Name
+	if _, ok := builtinKinds[t.name]; !ok && !annotated[t.name] {
+		return nil, fmt.Errorf("type %s is not a builtin type or an annotated struct", t.name)
+	}
+	return t, nil
+}
+
+// zeroCheck returns the expression which reports whether v of the type is the zero value
+func zeroCheck(expr ast.Expr, annotated map[string]bool, v string) (string, error) {
+	switch e := expr.(type) {
+	case *ast.StarExpr, *ast.MapType, *ast.FuncType, *ast.ChanType, *ast.InterfaceType:
+		return v + " == nil", nil
+	case *ast.ArrayType:
+		if e.Len == nil {
+			return v + " == nil", nil
+		}
+	case *ast.Ident:
+		switch kind := builtinKinds[e.Name]; {
+		case kind == schema.String:
+			return v + ` == ""`, nil
+		case kind == schema.Bool:
+			return "!" + v, nil
+		case kind == schema.Number:
+			return v + " == 0", nil
+		case annotated[e.Name]:
+			return v + ".isZeroSchema()", nil
+		}
+	}
+	return "", fmt.Errorf("unsupported type %s", exprString(expr))
+}
+
+func exprString(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.StarExpr:
+		return "*" + exprString(e.X)
+	case *ast.SelectorExpr:
+		return exprString(e.X) + "." + e.Sel.Name
+	case *ast.ArrayType:
+		return "[]" + exprString(e.Elt)
+	case *ast.MapType:
+		return "map[" + exprString(e.Key) + "]" + exprString(e.Value)
+	}
+	return fmt.Sprintf("%T", expr)
+}
@@ -0,0 +1,283 @@
+// Copyright 2020 orivil.com. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found at https://mit-license.org.
+
+package schema
+
+import (
+	"fmt"
+	"github.com/orivil/types"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// RootField is the prefix of the field paths which are resolved from the root value, such as
+// `schema:"gtField:$.start"`, other paths are resolved from the parent object, such as "period.start"
+const RootField = "$."
+
+// the comparison rules in order, see Validations.EqField
+var fieldRules = []string{EqField, NeField, GtField, GteField, LtField, LteField}
+
+// fieldRule returns the field path of the comparison rule
+func (vs *Validations) fieldRule(rule string) string {
+	switch rule {
+	case EqField:
+		return vs.EqField
+	case NeField:
+		return vs.NeField
+	case GtField:
+		return vs.GtField
+	case GteField:
+		return vs.GteField
+	case LtField:
+		return vs.LtField
+	case LteField:
+		return vs.LteField
+	}
+	return ""
+}
+
+func (vs *Validations) hasFieldRules() bool {
+	return vs.EqField != "" || vs.NeField != "" || vs.GtField != "" || vs.GteField != "" || vs.LtField != "" ||
+		vs.LteField != ""
+}
+
+// newFieldRule returns the failure info of the comparison rule
+func newFieldRule(rule, field string) *Validations {
+	info := &Validations{}
+	switch rule {
+	case EqField:
+		info.EqField = field
+	case NeField:
+		info.NeField = field
+	case GtField:
+		info.GtField = field
+	case GteField:
+		info.GteField = field
+	case LtField:
+		info.LtField = field
+	case LteField:
+		info.LteField = field
+	}
+	return info
+}
+
+// WithEqField makes the value equal to the value of the field, such as a "password_confirm" which
+// equals the "password", the field is a sibling field, a dotted path from the parent object or a path
+// from the root value, see RootField
+func (s *Schema) WithEqField(field string) *Schema {
+	return s.withFieldRule(EqField, field)
+}
+
+// WithNeField makes the value not equal to the value of the field
+func (s *Schema) WithNeField(field string) *Schema {
+	return s.withFieldRule(NeField, field)
+}
+
+// WithGtField makes the value greater than the value of the field, such as an "end_time" which is after
+// the "start_time"
+func (s *Schema) WithGtField(field string) *Schema {
+	return s.withFieldRule(GtField, field)
+}
+
+// WithGteField makes the value greater than or equal to the value of the field
+func (s *Schema) WithGteField(field string) *Schema {
+	return s.withFieldRule(GteField, field)
+}
+
+// WithLtField makes the value less than the value of the field
+func (s *Schema) WithLtField(field string) *Schema {
+	return s.withFieldRule(LtField, field)
+}
+
+// WithLteField makes the value less than or equal to the value of the field
+func (s *Schema) WithLteField(field string) *Schema {
+	return s.withFieldRule(LteField, field)
+}
+
+func (s *Schema) withFieldRule(rule, field string) *Schema {
+	s.initValidation()
+	vs := s.Validations
+	switch rule {
+	case EqField:
+		vs.EqField = field
+	case NeField:
+		vs.NeField = field
+	case GtField:
+		vs.GtField = field
+	case GteField:
+		vs.GteField = field
+	case LtField:
+		vs.LtField = field
+	case LteField:
+		vs.LteField = field
+	}
+	return s
+}
+
+func (s *Schema) withFieldTagOptions(opts tagOptions) {
+	for _, rule := range fieldRules {
+		if field := strings.TrimSpace(opts.GetValue(rule)); field != "" {
+			s.withFieldRule(rule, field)
+		}
+	}
+}
+
+// resolve returns the value and the path of the field which is referenced by a comparison rule
+func (vr *validator) resolve(path string) (v reflect.Value, field string) {
+	scope := vr.object
+	if strings.HasPrefix(path, RootField) {
+		scope = vr.root
+		path = path[len(RootField):]
+	}
+	if scope == nil {
+		// the root value is not an object
+		return reflect.Value{}, path
+	}
+	segs := strings.Split(path, ".")
+	v = scope.values[segs[0]]
+	for _, seg := range segs[1:] {
+		v = indirectInterface(reflect.Indirect(v))
+		switch v.Kind() {
+		case reflect.Struct:
+			var next reflect.Value
+			for _, f := range getStructFields(v) {
+				if f.property == seg {
+					next = f.raw
+					break
+				}
+			}
+			v = next
+		case reflect.Map:
+			v = v.MapIndex(reflect.ValueOf(seg).Convert(v.Type().Key()))
+		default:
+			v = reflect.Value{}
+		}
+	}
+	return v, initFieldName(scope.field, path)
+}
+
+// compareFields returns the failure info of the first failed comparison rule, the rules are skipped if
+// the referenced field is nil
+func (vr *validator) compareFields(vs *Validations, actual interface{}) (info *Validations, err error) {
+	if vr.object == nil || actual == nil {
+		return nil, nil
+	}
+	for _, rule := range fieldRules {
+		path := vs.fieldRule(rule)
+		if path == "" {
+			continue
+		}
+		v, field := vr.resolve(path)
+		other := valueInterface(v)
+		if other == nil {
+			continue
+		}
+		var ok bool
+		ok, err = compareValues(rule, actual, other)
+		if err != nil {
+			return nil, fmt.Errorf("schema rule %s of [%s] got error: %v", rule, field, err)
+		}
+		if !ok {
+			return newFieldRule(rule, field), nil
+		}
+	}
+	return nil, nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// compareValues reports whether a and b satisfy the comparison rule, they should be both numbers, both
+// strings or both times, a string is compared with a time as an RFC 3339 time. The values of different
+// kinds, such as a number and a string of a JSON payload, do not satisfy the rule, an error is only
+// returned if the values of the kind could not be compared, which is a fault of the schema
+func compareValues(rule string, a, b interface{}) (bool, error) {
+	cmp, ok, err := compare(a, b)
+	if err != nil || !ok {
+		return false, err
+	}
+	switch rule {
+	case EqField:
+		return cmp == 0, nil
+	case NeField:
+		return cmp != 0, nil
+	case GtField:
+		return cmp > 0, nil
+	case GteField:
+		return cmp >= 0, nil
+	case LtField:
+		return cmp < 0, nil
+	case LteField:
+		return cmp <= 0, nil
+	}
+	return false, fmt.Errorf("unknown rule %s", rule)
+}
+
+// compare returns the order of a and b, ok reports whether a and b are of the same kind
+func compare(a, b interface{}) (cmp int, ok bool, err error) {
+	ta, aTime := timeValue(a)
+	tb, bTime := timeValue(b)
+	if aTime || bTime {
+		if str, ok := a.(string); ok {
+			ta, err = time.Parse(time.RFC3339Nano, str)
+			aTime = err == nil
+		}
+		if str, ok := b.(string); ok {
+			tb, err = time.Parse(time.RFC3339Nano, str)
+			bTime = err == nil
+		}
+		if !aTime || !bTime {
+			return 0, false, nil
+		}
+		switch {
+		case ta.Before(tb):
+			return -1, true, nil
+		case ta.After(tb):
+			return 1, true, nil
+		}
+		return 0, true, nil
+	}
+	ka, kb := GoToJSONType(reflect.TypeOf(a)), GoToJSONType(reflect.TypeOf(b))
+	if ka != kb {
+		return 0, false, nil
+	}
+	if ka != Number && ka != String && ka != Bool {
+		return 0, false, fmt.Errorf("can not compare %T with %T", a, b)
+	}
+	va, err := types.GetValue(a)
+	if err != nil {
+		return 0, false, err
+	}
+	vb, err := types.GetValue(b)
+	if err != nil {
+		return 0, false, err
+	}
+	if ka != Number {
+		return strings.Compare(va.String(), vb.String()), true, nil
+	}
+	fa, err := va.Float64()
+	if err != nil {
+		return 0, false, err
+	}
+	fb, err := vb.Float64()
+	if err != nil {
+		return 0, false, err
+	}
+	switch {
+	case fa < fb:
+		return -1, true, nil
+	case fa > fb:
+		return 1, true, nil
+	}
+	return 0, true, nil
+}
+
+// timeValue returns the time of v if v is a time.Time or a struct type defined by time.Time
+func timeValue(v interface{}) (time.Time, bool) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Struct && rv.Type().ConvertibleTo(timeType) {
+		return rv.Convert(timeType).Interface().(time.Time), true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,184 @@
+// Copyright 2020 orivil.com. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found at https://mit-license.org.
+
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Condition is the condition of the "requiredIf" rule, it is met if the sibling field equals one of
+// the values, such as `schema:"requiredIf:type=2,3"`
+type Condition struct {
+	Field  string   `json:"field"`
+	Values []string `json:"values"`
+}
+
+func (c Condition) String() string {
+	return c.Field + "=" + strings.Join(c.Values, ",")
+}
+
+func parseCondition(str string) (*Condition, error) {
+	idx := strings.Index(str, "=")
+	if idx <= 0 {
+		return nil, fmt.Errorf("need condition such as \"field=value\", got %q", str)
+	}
+	c := &Condition{Field: strings.TrimSpace(str[:idx])}
+	for _, value := range strings.Split(str[idx+1:], ",") {
+		c.Values = append(c.Values, strings.TrimSpace(value))
+	}
+	return c, nil
+}
+
+// hasSiblingRules reports whether the rules depend on the sibling fields, which are not supported by
+// the generated code
+func (vs *Validations) hasSiblingRules() bool {
+	return vs.RequiredIf != nil || vs.RequiredWith != nil || vs.RequiredWithout != nil || vs.hasFieldRules()
+}
+
+// siblingFields returns the sibling fields of the rules keyed by the rule names, the paths from the root
+// value are not checked
+func (vs *Validations) siblingFields() map[string][]string {
+	fields := make(map[string][]string)
+	if vs.RequiredIf != nil {
+		fields[RequiredIf] = []string{vs.RequiredIf.Field}
+	}
+	if vs.RequiredWith != nil {
+		fields[RequiredWith] = vs.RequiredWith
+	}
+	if vs.RequiredWithout != nil {
+		fields[RequiredWithout] = vs.RequiredWithout
+	}
+	for _, rule := range fieldRules {
+		// only the first segment of a path from the parent object is a sibling field
+		if path := vs.fieldRule(rule); path != "" && !strings.HasPrefix(path, RootField) {
+			fields[rule] = []string{strings.SplitN(path, ".", 2)[0]}
+		}
+	}
+	return fields
+}
+
+// checkSiblings checks the sibling fields of the rules of the properties
+func (s *Schema) checkSiblings() error {
+	for _, property := range s.Properties {
+		if property.Validations == nil {
+			continue
+		}
+		for rule, fields := range property.Validations.siblingFields() {
+			for _, field := range fields {
+				if s.Property(field) == nil {
+					return &TagError{Tag: Tag + "." + rule, Err: fmt.Sprintf("field %s: unknown sibling field %q", property.Name, field)}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Schema) withConditionalTagOptions(opts tagOptions) error {
+	if str := opts.GetValue(RequiredIf); str != "" {
+		c, err := parseCondition(str)
+		if err != nil {
+			return &TagError{Tag: Tag + "." + RequiredIf, Err: err.Error()}
+		}
+		s.WithRequiredIf(c.Field, c.Values...)
+	}
+	if str := opts.GetValue(RequiredWith); str != "" {
+		s.WithRequiredWith(splitFields(str)...)
+	}
+	if str := opts.GetValue(RequiredWithout); str != "" {
+		s.WithRequiredWithout(splitFields(str)...)
+	}
+	return nil
+}
+
+func splitFields(str string) []string {
+	fields := strings.Split(str, ",")
+	for i, field := range fields {
+		fields[i] = strings.TrimSpace(field)
+	}
+	return fields
+}
+
+// WithRequiredIf makes the value required if the sibling field equals one of the values, such as a
+// "company" which is required when "type" is 2
+func (s *Schema) WithRequiredIf(field string, values ...string) *Schema {
+	s.initValidation()
+	s.Validations.RequiredIf = &Condition{Field: field, Values: values}
+	return s
+}
+
+// WithRequiredWith makes the value required if any of the sibling fields is supplied
+func (s *Schema) WithRequiredWith(fields ...string) *Schema {
+	s.initValidation()
+	s.Validations.RequiredWith = append(s.Validations.RequiredWith, fields...)
+	return s
+}
+
+// WithRequiredWithout makes the value required if any of the sibling fields is not supplied, such as
+// "email" and "mobile" which require each other when absent, so that one of them is required
+func (s *Schema) WithRequiredWithout(fields ...string) *Schema {
+	s.initValidation()
+	s.Validations.RequiredWithout = append(s.Validations.RequiredWithout, fields...)
+	return s
+}
+
+// objectScope is the object whose properties are being validated, the sibling rules are checked by it
+type objectScope struct {
+	field  string
+	schema *Schema
+	values map[string]reflect.Value
+}
+
+// enter makes the properties of the object the siblings, it returns a function for restoring
+func (vr *validator) enter(field string, s *Schema, values map[string]reflect.Value) (restore func()) {
+	object := vr.object
+	vr.object = &objectScope{field: field, schema: s, values: values}
+	if field == "" {
+		vr.root = vr.object
+	}
+	return func() {
+		vr.object = object
+	}
+}
+
+// siblingSupplied reports whether the sibling field is supplied under its own required mode
+func (vr *validator) siblingSupplied(name string) bool {
+	s := vr.object.schema.Property(name)
+	if s == nil {
+		s = &Schema{}
+	}
+	return vr.supplied(s, initFieldName(vr.object.field, name), vr.object.values[name])
+}
+
+// requiredBy returns the failure info of the sibling rule which makes the value required, it returns nil
+// if the value is not required by the siblings or the siblings are unknown, such as for the generated code
+func (vr *validator) requiredBy(vs *Validations) *Validations {
+	if vr.object == nil {
+		return nil
+	}
+	if c := vs.RequiredIf; c != nil {
+		if actual := valueInterface(vr.object.values[c.Field]); actual != nil {
+			str := fmt.Sprint(actual)
+			for _, value := range c.Values {
+				if value == str {
+					return &Validations{RequiredIf: c}
+				}
+			}
+		}
+	}
+	for _, name := range vs.RequiredWith {
+		if vr.siblingSupplied(name) {
+			return &Validations{RequiredWith: vs.RequiredWith}
+		}
+	}
+	for _, name := range vs.RequiredWithout {
+		if !vr.siblingSupplied(name) {
+			return &Validations{RequiredWithout: vs.RequiredWithout}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+// Copyright 2020 orivil.com. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found at https://mit-license.org.
+
+package schema
+
+import (
+	"encoding"
+	"encoding/json"
+	"io"
+	"reflect"
+)
+
+// Presence holds the field paths of the supplied values, the paths are the same as Validations.Field
+type Presence map[string]struct{}
+
+func (p Presence) Has(field string) bool {
+	_, ok := p[field]
+	return ok
+}
+
+func (p Presence) add(field string) {
+	p[field] = struct{}{}
+}
+
+// JSONDecoder reads JSON values from an input stream, validates them by a schema and fills the targets
+type JSONDecoder struct {
+	dec      *json.Decoder
+	schema   *Schema
+	presence Presence
+}
+
+func NewJSONDecoder(r io.Reader, s *Schema) *JSONDecoder {
+	return &JSONDecoder{dec: json.NewDecoder(r), schema: s}
+}
+
+// Decode reads the next JSON value, fills v and returns the first failure, v could be nil if only
+// validating is needed
+func (d *JSONDecoder) Decode(v interface{}, opts ...ValidOption) (info *Validations, err error) {
+	vr, err := d.decode(v, false, opts)
+	if err != nil || len(vr.failures) == 0 {
+		return nil, err
+	}
+	if vr.asError {
+		return nil, vr.failures[0]
+	}
+	return vr.failures[0].validations, nil
+}
+
+// DecodeAll is the same as Decode but returns all the failures
+func (d *JSONDecoder) DecodeAll(v interface{}, opts ...ValidOption) (infos []*Validations, err error) {
+	vr, err := d.decode(v, true, opts)
+	if err != nil {
+		return nil, err
+	}
+	return vr.result()
+}
+
+// Presence returns the field paths of the values supplied by the last decoded JSON value
+func (d *JSONDecoder) Presence() Presence {
+	return d.presence
+}
+
+func (d *JSONDecoder) decode(v interface{}, all bool, opts []ValidOption) (*validator, error) {
+	var raw json.RawMessage
+	err := d.dec.Decode(&raw)
+	if err != nil {
+		return nil, err
+	}
+	var tree interface{}
+	err = json.Unmarshal(raw, &tree)
+	if err != nil {
+		return nil, err
+	}
+	d.presence = make(Presence)
+	addPresence(d.presence, "", tree)
+	vr := d.schema.newValidator(all, opts)
+	err = d.schema.valid(vr, "", reflect.ValueOf(tree))
+	if err != nil {
+		return nil, err
+	}
+	if v != nil {
+		err = json.Unmarshal(raw, v)
+		if _, ok := err.(*json.UnmarshalTypeError); ok && len(vr.failures) > 0 {
+			// the type errors are reported by failures
+			err = nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return vr, nil
+}
+
+func addPresence(p Presence, field string, v interface{}) {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		for key, value := range vv {
+			if value != nil {
+				name := initFieldName(field, key)
+				p.add(name)
+				addPresence(p, name, value)
+			}
+		}
+	case []interface{}:
+		for i, item := range vv {
+			if item != nil {
+				name := initItemName(field, i)
+				p.add(name)
+				addPresence(p, name, item)
+			}
+		}
+	}
+}
+
+// isJSONKind reports whether the dynamic value v, such as a value decoded from JSON, is kind of k, a null
+// value is any kind and the values of the other Go types are not checked
+func isJSONKind(k JsonKind, v reflect.Value) bool {
+	if isNilValue(v) || !v.CanInterface() {
+		return true
+	}
+	switch v.Interface().(type) {
+	case string:
+		return k == String || k == File || k == ""
+	case float64:
+		return k == Number || k == ""
+	case bool:
+		return k == Bool || k == ""
+	case []interface{}:
+		return k == Array || k == ""
+	case map[string]interface{}:
+		return k == Object || k == ""
+	}
+	return true
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf(new(json.Unmarshaler)).Elem()
+	textUnmarshalerType = reflect.TypeOf(new(encoding.TextUnmarshaler)).Elem()
+)
+
+// isUnmarshaler reports whether the values of t decode the JSON values themselves, such as time.Time
+// which is decoded from a string
+func isUnmarshaler(t reflect.Type) bool {
+	pt := reflect.PtrTo(t)
+	return pt.Implements(jsonUnmarshalerType) || pt.Implements(textUnmarshalerType)
+}
+
+// isBytes reports whether t is a slice of bytes such as []byte, which is encoded as a base64 string by
+// encoding/json
+func isBytes(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
@@ -0,0 +1,125 @@
+// Copyright 2020 orivil.com. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found at https://mit-license.org.
+
+package schema_test
+
+import (
+	"github.com/orivil/schema"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONDecoder(t *testing.T) {
+	type item struct {
+		ID int `json:"id" schema:"required"`
+	}
+	type order struct {
+		Count  int     `json:"count" schema:"required;maxNum:10"`
+		Paid   bool    `json:"paid" schema:"required"`
+		Remark string  `json:"remark" schema:"maxLen:4"`
+		Items  []*item `json:"items"`
+	}
+	s, err := schema.NewSchema(&order{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := `{"count": 0, "paid": false, "items": [{"id": 0}]}
+{"count": "1", "remark": "12345", "items": [{}]}`
+	dec := schema.NewJSONDecoder(strings.NewReader(input), s)
+	o := &order{}
+	infos, err := dec.DecodeAll(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) > 0 {
+		t.Fatalf("need no failures, got: %s", jsonStr(infos))
+	}
+	if len(o.Items) != 1 {
+		t.Fatalf("the target is not filled: %s", jsonStr(o))
+	}
+	for _, field := range []string{"count", "paid", "items", "items[0]", "items[0].id"} {
+		if !dec.Presence().Has(field) {
+			t.Errorf("need presence of %s", field)
+		}
+	}
+	if dec.Presence().Has("remark") {
+		t.Errorf("need absence of remark")
+	}
+	infos, err = dec.DecodeAll(&order{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	need := [][2]string{
+		{"count", schema.RuleType},
+		{"paid", schema.OptionsRequired},
+		{"remark", schema.MaxLen},
+		{"items[0].id", schema.OptionsRequired},
+	}
+	if len(infos) != len(need) {
+		t.Fatalf("need %d failures, got: %s", len(need), jsonStr(infos))
+	}
+	for i, info := range infos {
+		if info.Field != need[i][0] || info.Rule() != need[i][1] {
+			t.Errorf("need: %v, got: %s %s\n", need[i], info.Field, info.Rule())
+		}
+	}
+}
+
+func TestJSONDecoderUnmarshaler(t *testing.T) {
+	// the Time values are decoded from the strings by time.Time itself
+	type event struct {
+		Start time.Time `json:"start" schema:"required"`
+		End   time.Time `json:"end"`
+	}
+	s, err := schema.NewSchema(&event{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := `{"start": "2020-01-02T15:04:05Z", "end": "2020-01-03T15:04:05+08:00"}`
+	e := &event{}
+	infos, err := schema.NewJSONDecoder(strings.NewReader(input), s).DecodeAll(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) > 0 {
+		t.Fatalf("need no failures, got: %s", jsonStr(infos))
+	}
+	if e.Start.Year() != 2020 || e.End.Day() != 3 {
+		t.Fatalf("the target is not filled: %s", jsonStr(e))
+	}
+}
+
+func TestJSONDecoderBytes(t *testing.T) {
+	// the bytes are encoded as the base64 strings by encoding/json
+	type upload struct {
+		Data []byte          `json:"data" schema:"required"`
+		File schema.FileData `json:"file"`
+	}
+	s, err := schema.NewSchema(&upload{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := `{"data": "aGVsbG8=", "file": "d29ybGQ="}
+{"data": 1}`
+	dec := schema.NewJSONDecoder(strings.NewReader(input), s)
+	u := &upload{}
+	infos, err := dec.DecodeAll(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) > 0 {
+		t.Fatalf("need no failures, got: %s", jsonStr(infos))
+	}
+	if string(u.Data) != "hello" || string(u.File) != "world" {
+		t.Fatalf("the target is not filled: %s %s", u.Data, u.File)
+	}
+	infos, err = dec.DecodeAll(&upload{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 || infos[0].Field != "data" || infos[0].Rule() != schema.RuleType {
+		t.Fatalf("need type failure of data, got: %s", jsonStr(infos))
+	}
+}
@@ -0,0 +1,308 @@
+// Copyright 2020 orivil.com. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found at https://mit-license.org.
+
+package schema
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/orivil/types"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// FileInfo is implemented by the file values which could be validated by the file rules, such as
+// "maxSize" and "mime"
+type FileInfo interface {
+	// Name returns the file name, it could be empty
+	Name() string
+	Size() int64
+	Open() (io.ReadCloser, error)
+}
+
+func (f FileData) Name() string {
+	return ""
+}
+
+func (f FileData) Size() int64 {
+	return int64(len(f))
+}
+
+func (f FileData) Open() (io.ReadCloser, error) {
+	return ioutil.NopCloser(bytes.NewReader(f)), nil
+}
+
+// FormFile keeps the header of an uploaded file, the content is not read until it is opened
+type FormFile struct {
+	Header *multipart.FileHeader
+}
+
+func (f *FormFile) Read(header multipart.FileHeader) error {
+	f.Header = &header
+	return nil
+}
+
+func (f FormFile) Name() string {
+	if f.Header == nil {
+		return ""
+	}
+	return f.Header.Filename
+}
+
+func (f FormFile) Size() int64 {
+	if f.Header == nil {
+		return 0
+	}
+	return f.Header.Size
+}
+
+func (f FormFile) Open() (io.ReadCloser, error) {
+	if f.Header == nil {
+		return nil, fmt.Errorf("file is not uploaded")
+	}
+	return f.Header.Open()
+}
+
+func (vs *Validations) isFileValidations() bool {
+	return vs.MaxSize != nil || vs.MinSize != nil || vs.Mime != nil || vs.Ext != nil || vs.isImageValidations()
+}
+
+func (vs *Validations) isImageValidations() bool {
+	return vs.MaxWidth != nil || vs.MinWidth != nil || vs.MaxHeight != nil || vs.MinHeight != nil
+}
+
+func (vs *Validations) validFile(v interface{}) (info *Validations, err error) {
+	f, ok := v.(FileInfo)
+	if !ok || !vs.isFileValidations() {
+		return nil, nil
+	}
+	size := f.Size()
+	if vs.MinSize != nil && *vs.MinSize > size {
+		return &Validations{MinSize: vs.MinSize}, nil
+	}
+	if vs.MaxSize != nil && *vs.MaxSize < size {
+		return &Validations{MaxSize: vs.MaxSize}, nil
+	}
+	// a file without a name has no extension, the FileData fields could not have the rule, see checkExt
+	if vs.Ext != nil {
+		ext := strings.ToLower(path.Ext(f.Name()))
+		if !containsString(vs.Ext, ext) {
+			return &Validations{Ext: vs.Ext}, nil
+		}
+	}
+	if vs.Mime == nil && !vs.isImageValidations() {
+		return nil, nil
+	}
+	var rc io.ReadCloser
+	rc, err = f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	head := make([]byte, 512)
+	n, err := io.ReadFull(rc, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	head = head[:n]
+	if vs.Mime != nil && !matchMime(vs.Mime, http.DetectContentType(head)) {
+		return &Validations{Mime: vs.Mime}, nil
+	}
+	if vs.isImageValidations() {
+		config, _, err := image.DecodeConfig(io.MultiReader(bytes.NewReader(head), rc))
+		if err != nil {
+			return &Validations{Image: true}, nil
+		}
+		switch {
+		case vs.MinWidth != nil && *vs.MinWidth > config.Width:
+			return &Validations{MinWidth: vs.MinWidth}, nil
+		case vs.MaxWidth != nil && *vs.MaxWidth < config.Width:
+			return &Validations{MaxWidth: vs.MaxWidth}, nil
+		case vs.MinHeight != nil && *vs.MinHeight > config.Height:
+			return &Validations{MinHeight: vs.MinHeight}, nil
+		case vs.MaxHeight != nil && *vs.MaxHeight < config.Height:
+			return &Validations{MaxHeight: vs.MaxHeight}, nil
+		}
+	}
+	return nil, nil
+}
+
+// matchMime reports whether the sniffed content type matches one of the types, such as "image/png"
+// or "image/*"
+func matchMime(mediaTypes []string, contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range mediaTypes {
+		if t == mediaType || strings.HasSuffix(t, "/*") && strings.HasPrefix(mediaType, t[:len(t)-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(strs []string, str string) bool {
+	for _, s := range strs {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
+
+var sizeUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// parseSize parses the file size, such as "1024", "512KB" or "2MB"
+func parseSize(str string) (int64, error) {
+	str = strings.ToUpper(strings.TrimSpace(str))
+	unit := int64(1)
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(str, u.suffix) {
+			str = strings.TrimSpace(strings.TrimSuffix(str, u.suffix))
+			unit = u.size
+			break
+		}
+	}
+	f64, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return 0, err
+	}
+	return int64(f64 * float64(unit)), nil
+}
+
+// withFileTagOptions sets the rules of files and booleans
+func (s *Schema) withFileTagOptions(opts tagOptions) error {
+	if str := opts.GetValue(Const); str != "" {
+		b, err := strconv.ParseBool(str)
+		if err != nil {
+			return &TagError{Tag: Tag + "." + Const, Err: err.Error()}
+		}
+		s.WithConst(b)
+	}
+	for _, name := range []string{MinSize, MaxSize} {
+		if str := opts.GetValue(name); str != "" {
+			size, err := parseSize(str)
+			if err != nil {
+				return &TagError{Tag: Tag + "." + name, Err: err.Error()}
+			}
+			if name == MinSize {
+				s.WithMinSize(size)
+			} else {
+				s.WithMaxSize(size)
+			}
+		}
+	}
+	if str := opts.GetValue(Mime); str != "" {
+		s.WithMime(strings.Split(str, ",")...)
+	}
+	if str := opts.GetValue(Ext); str != "" {
+		s.WithExt(strings.Split(str, ",")...)
+	}
+	for _, name := range []string{MinWidth, MaxWidth, MinHeight, MaxHeight} {
+		if str := opts.GetValue(name); str != "" {
+			i, err := types.String(str).Int()
+			if err != nil {
+				return &TagError{Tag: Tag + "." + name, Err: err.Error()}
+			}
+			s.withImageLimit(name, i)
+		}
+	}
+	return nil
+}
+
+// checkExt rejects the "ext" rule of the FileData fields, whose file names are not kept when reading
+// the uploaded files, the FormFile fields should be used instead
+func (s *Schema) checkExt(t reflect.Type) error {
+	if s.Validations != nil && s.Validations.Ext != nil && indirectType(t) == reflect.TypeOf(FileData(nil)) {
+		return &TagError{Tag: Tag + "." + Ext, Err: "the file names of FileData are unknown, use FormFile instead"}
+	}
+	return nil
+}
+
+// WithConst sets the only valid value of booleans, such as true for a terms-accepted checkbox
+func (s *Schema) WithConst(b bool) *Schema {
+	s.initValidation()
+	s.Validations.Const = &b
+	return s
+}
+
+func (s *Schema) WithMinSize(size int64) *Schema {
+	s.initValidation()
+	s.Validations.MinSize = &size
+	return s
+}
+
+func (s *Schema) WithMaxSize(size int64) *Schema {
+	s.initValidation()
+	s.Validations.MaxSize = &size
+	return s
+}
+
+// WithMime sets the allowed media types of files, the types are checked by sniffing the content, a
+// type could be a wildcard such as "image/*"
+func (s *Schema) WithMime(mediaTypes ...string) *Schema {
+	s.initValidation()
+	for _, t := range mediaTypes {
+		s.Validations.Mime = append(s.Validations.Mime, strings.ToLower(strings.TrimSpace(t)))
+	}
+	return s
+}
+
+// WithExt sets the allowed extensions of file names, such as ".png"
+func (s *Schema) WithExt(exts ...string) *Schema {
+	s.initValidation()
+	for _, ext := range exts {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		s.Validations.Ext = append(s.Validations.Ext, ext)
+	}
+	return s
+}
+
+// WithImageSize sets the limits of the image dimensions in pixels, a limit less than 0 is ignored
+func (s *Schema) WithImageSize(minWidth, maxWidth, minHeight, maxHeight int) *Schema {
+	limits := []int{minWidth, maxWidth, minHeight, maxHeight}
+	for i, name := range []string{MinWidth, MaxWidth, MinHeight, MaxHeight} {
+		if limits[i] >= 0 {
+			s.withImageLimit(name, limits[i])
+		}
+	}
+	return s
+}
+
+func (s *Schema) withImageLimit(name string, limit int) {
+	s.initValidation()
+	switch name {
+	case MinWidth:
+		s.Validations.MinWidth = &limit
+	case MaxWidth:
+		s.Validations.MaxWidth = &limit
+	case MinHeight:
+		s.Validations.MinHeight = &limit
+	case MaxHeight:
+		s.Validations.MaxHeight = &limit
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2020 orivil.com. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found at https://mit-license.org.
+
+package schema_test
+
+import (
+	"bytes"
+	"github.com/orivil/schema"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"testing"
+)
+
+func pngData(width, height int) []byte {
+	buf := &bytes.Buffer{}
+	err := png.Encode(buf, image.NewGray(image.Rect(0, 0, width, height)))
+	if err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+// newMultipartForm builds a form with the files, the keys of files are the field names and file names
+func newMultipartForm(values map[string][]string, files map[string][2]string) *multipart.Form {
+	buf := &bytes.Buffer{}
+	w := multipart.NewWriter(buf)
+	for key, vs := range values {
+		for _, v := range vs {
+			if err := w.WriteField(key, v); err != nil {
+				panic(err)
+			}
+		}
+	}
+	for key, file := range files {
+		fw, err := w.CreateFormFile(key, file[0])
+		if err != nil {
+			panic(err)
+		}
+		if _, err = fw.Write([]byte(file[1])); err != nil {
+			panic(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		panic(err)
+	}
+	form, err := multipart.NewReader(buf, w.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		panic(err)
+	}
+	return form
+}
+
+func TestFileRules(t *testing.T) {
+	type model struct {
+		Avatar schema.FileData `json:"avatar" schema:"mime:image/*;maxWidth:16;minHeight:4"`
+		Photo  schema.FileData `json:"photo" schema:"maxWidth:16"`
+		Doc    schema.FormFile `json:"doc" schema:"required;ext:txt,md;maxSize:1KB"`
+		Terms  bool            `json:"terms" schema:"const:true"`
+	}
+	s, err := schema.NewSchema(&model{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Property("doc").Type != schema.File || s.Property("doc").Properties != nil {
+		t.Fatalf("need File kind, got: %s", jsonStr(s.Property("doc")))
+	}
+	newDoc := func(name, content string) schema.FormFile {
+		form := newMultipartForm(nil, map[string][2]string{"doc": {name, content}})
+		return schema.FormFile{Header: form.File["doc"][0]}
+	}
+	type testCase struct {
+		v    *model
+		rule string
+	}
+	testCases := []testCase{
+		{&model{Avatar: pngData(16, 4), Doc: newDoc("a.md", "# title"), Terms: true}, ""},
+		{&model{Avatar: pngData(16, 4), Doc: newDoc("a.md", "# title")}, schema.Const},
+		{&model{Doc: newDoc("a.md", "# title"), Terms: true}, ""},
+		{&model{Avatar: schema.FileData("text"), Doc: newDoc("a.md", "# title"), Terms: true}, schema.Mime},
+		{&model{Avatar: pngData(17, 4), Doc: newDoc("a.md", "# title"), Terms: true}, schema.MaxWidth},
+		{&model{Avatar: pngData(16, 3), Doc: newDoc("a.md", "# title"), Terms: true}, schema.MinHeight},
+		{&model{Photo: schema.FileData("text"), Doc: newDoc("a.md", "# title"), Terms: true}, schema.RuleImage},
+		{&model{Terms: true}, schema.OptionsRequired},
+		{&model{Doc: newDoc("a.exe", "MZ"), Terms: true}, schema.Ext},
+		{&model{Doc: newDoc("a.TXT", string(make([]byte, 1025))), Terms: true}, schema.MaxSize},
+	}
+	for i, tc := range testCases {
+		_, err = s.Valid(tc.v, schema.AsError())
+		if tc.rule == "" {
+			if err != nil {
+				t.Errorf("case %d need no failure, got: %v", i, err)
+			}
+		} else if e, ok := err.(*schema.ValidationError); !ok || e.Rule != tc.rule {
+			t.Errorf("case %d need failure of %s, got: %v", i, tc.rule, err)
+		}
+	}
+}
+
+func TestFileExt(t *testing.T) {
+	// the file names of FileData are unknown
+	type model struct {
+		Doc schema.FileData `json:"doc" schema:"ext:txt"`
+	}
+	_, err := schema.NewSchema(&model{})
+	if e, ok := err.(*schema.TagError); !ok || e.Tag != schema.Tag+"."+schema.Ext {
+		t.Fatalf("need *TagError of ext, got: %v", err)
+	}
+	// a file without a name has no extension
+	s := (&schema.Schema{Type: schema.File}).WithExt("txt")
+	_, err = s.Valid(schema.FormFile{Header: &multipart.FileHeader{Size: 1}}, schema.AsError())
+	if e, ok := err.(*schema.ValidationError); !ok || e.Rule != schema.Ext {
+		t.Fatalf("need failure of ext, got: %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2020 orivil.com. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found at https://mit-license.org.
+
+package schema
+
+import (
+	"net"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// the string formats of the "format" option, the names are the same as JSON Schema
+const (
+	FormatEmail    = "email"
+	FormatHostname = "hostname"
+	FormatURI      = "uri"
+	FormatUUID     = "uuid"
+	FormatDate     = "date"
+	// FormatDateTime is the RFC 3339 date-time, such as "2020-01-02T15:04:05Z"
+	FormatDateTime = "date-time"
+	// FormatDuration is the ISO 8601 duration, such as "P3DT12H"
+	FormatDuration = "duration"
+	FormatIPv4     = "ipv4"
+	FormatIPv6     = "ipv6"
+	FormatCIDR     = "cidr"
+	FormatMAC      = "mac"
+)
+
+var formatCheckers = map[string]func(str string) bool{
+	FormatEmail:    isEmail,
+	FormatHostname: isHostname,
+	FormatURI:      isURI,
+	FormatUUID:     isUUID,
+	FormatDate: func(str string) bool {
+		_, err := time.Parse("2006-01-02", str)
+		return err == nil
+	},
+	FormatDateTime: func(str string) bool {
+		_, err := time.Parse(time.RFC3339Nano, str)
+		return err == nil
+	},
+	FormatDuration: isDuration,
+	FormatIPv4: func(str string) bool {
+		ip := net.ParseIP(str)
+		return ip != nil && ip.To4() != nil && !strings.Contains(str, ":")
+	},
+	FormatIPv6: func(str string) bool {
+		return net.ParseIP(str) != nil && strings.Contains(str, ":")
+	},
+	FormatCIDR: func(str string) bool {
+		_, _, err := net.ParseCIDR(str)
+		return err == nil
+	},
+	FormatMAC: func(str string) bool {
+		_, err := net.ParseMAC(str)
+		return err == nil
+	},
+}
+
+func isFormat(format, str string) bool {
+	checker, ok := formatCheckers[format]
+	return ok && checker(str)
+}
+
+// isEmail accepts the bare address only, such as "jay@example.com" but not "Jay <jay@example.com>"
+func isEmail(str string) bool {
+	addr, err := mail.ParseAddress(str)
+	if err != nil || addr.Address != str {
+		return false
+	}
+	return isHostname(str[strings.LastIndex(str, "@")+1:])
+}
+
+// isHostname checks the host name by RFC 1123
+func isHostname(str string) bool {
+	str = strings.TrimSuffix(str, ".")
+	if len(str) == 0 || len(str) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(str, ".") {
+		if len(label) == 0 || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for i := 0; i < len(label); i++ {
+			c := label[i]
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isURI accepts the absolute URI only
+func isURI(str string) bool {
+	u, err := url.Parse(str)
+	return err == nil && u.IsAbs()
+}
+
+func isUUID(str string) bool {
+	if len(str) != 36 {
+		return false
+	}
+	for i := 0; i < len(str); i++ {
+		c := str[i]
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isDuration checks the ISO 8601 duration, such as "P1Y2M3DT4H5M6S" or "P2W"
+func isDuration(str string) bool {
+	if len(str) < 2 || str[0] != 'P' {
+		return false
+	}
+	units := "YMWD"
+	inTime := false
+	hasUnit := false
+	digits := 0
+	for i := 1; i < len(str); i++ {
+		c := str[i]
+		switch {
+		case c >= '0' && c <= '9':
+			digits++
+		case c == 'T':
+			if inTime || digits > 0 {
+				return false
+			}
+			inTime = true
+			units = "HMS"
+			hasUnit = false
+		default:
+			idx := strings.IndexByte(units, c)
+			if idx == -1 || digits == 0 {
+				return false
+			}
+			// the units must be in order and appear once
+			units = units[idx+1:]
+			digits = 0
+			hasUnit = true
+		}
+	}
+	return digits == 0 && hasUnit
+}
@@ -0,0 +1,72 @@
+// Copyright 2020 orivil.com. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found at https://mit-license.org.
+
+package schema
+
+import (
+	"testing"
+)
+
+func TestFormats(t *testing.T) {
+	type testCase struct {
+		format  string
+		valid   []string
+		invalid []string
+	}
+	testCases := []testCase{
+		{FormatEmail, []string{"jay@example.com", "a.b+c@sub.example.cn"}, []string{"Jay <jay@example.com>", "jay@", "jay@-a.com", "jay"}},
+		{FormatHostname, []string{"example.com", "a-b.example.com.", "localhost"}, []string{"-a.com", "a..com", "a_b.com", ""}},
+		{FormatURI, []string{"https://example.com/a?b=c", "mailto:jay@example.com"}, []string{"/a/b", "example.com", "http://a b"}},
+		{FormatUUID, []string{"123e4567-e89b-12d3-a456-426614174000"}, []string{"123e4567e89b12d3a456426614174000", "123e4567-e89b-12d3-a456-42661417400g"}},
+		{FormatDate, []string{"2020-02-29"}, []string{"2021-02-29", "2020-1-1"}},
+		{FormatDateTime, []string{"2020-01-02T15:04:05Z", "2020-01-02T15:04:05.123+08:00"}, []string{"2020-01-02 15:04:05", "2020-01-02T15:04:05"}},
+		{FormatDuration, []string{"P1Y2M3DT4H5M6S", "PT36H", "P2W"}, []string{"P", "PT", "P1H", "P1D2Y", "1D", "P1DT"}},
+		{FormatIPv4, []string{"127.0.0.1"}, []string{"::1", "::ffff:127.0.0.1", "256.0.0.1"}},
+		{FormatIPv6, []string{"::1", "2001:db8::68"}, []string{"127.0.0.1", "2001:db8:::68"}},
+		{FormatCIDR, []string{"192.168.0.0/16", "2001:db8::/32"}, []string{"192.168.0.0", "192.168.0.0/33"}},
+		{FormatMAC, []string{"00:00:5e:00:53:01", "00-00-5E-00-53-01"}, []string{"00:00:5e:00:53", "00:00:5e:00:53:0g"}},
+	}
+	for _, tc := range testCases {
+		vs := &Validations{Format: tc.format}
+		for _, str := range tc.valid {
+			if info, _ := vs.validString(str, LenUnitByte); info != nil {
+				t.Errorf("%s: need valid %q", tc.format, str)
+			}
+		}
+		for _, str := range tc.invalid {
+			if info, _ := vs.validString(str, LenUnitByte); info == nil || info.Format != tc.format {
+				t.Errorf("%s: need invalid %q", tc.format, str)
+			}
+		}
+	}
+	s := &Schema{}
+	err := s.WithTagOptions(`schema:"format:phone"`)
+	if _, ok := err.(*TagError); !ok {
+		t.Errorf("need *TagError, got: %v", err)
+	}
+}
+
+func TestStringLength(t *testing.T) {
+	type testCase struct {
+		str                   string
+		bytes, runes, letters int
+	}
+	testCases := []testCase{
+		{"Jay", 3, 3, 3},
+		{"周杰伦", 9, 3, 3},
+		{"e\u0301", 3, 2, 1},
+		{"\U0001F1E8\U0001F1F3\U0001F1FA\U0001F1F8", 16, 4, 2},
+		{"\U0001F468\u200D\U0001F469\u200D\U0001F467", 18, 5, 1},
+		{"\U0001F44D\U0001F3FD", 8, 2, 1},
+		{"\u1100\u1161\u11A8", 9, 3, 1},
+		{"a\r\nb", 4, 4, 3},
+	}
+	for _, tc := range testCases {
+		got := [3]int{stringLength(tc.str, LenUnitByte), stringLength(tc.str, LenUnitRune), stringLength(tc.str, LenUnitGrapheme)}
+		need := [3]int{tc.bytes, tc.runes, tc.letters}
+		if got != need {
+			t.Errorf("%q need: %v, got: %v", tc.str, need, got)
+		}
+	}
+}
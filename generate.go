@@ -0,0 +1,138 @@
+// Copyright 2020 orivil.com. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found at https://mit-license.org.
+
+package schema
+
+import "reflect"
+
+// MustProperty sets the name and the tag options of the property schema s, it panics if the tag is
+// invalid, it is used by the code generated by cmd/schemagen whose tags are checked when generating
+func MustProperty(s *Schema, name, tag string) *Schema {
+	s = s.propertyOf()
+	s.Name = name
+	err := s.WithTagOptions(reflect.StructTag(tag))
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Checker walks the values for the Validate methods generated by cmd/schemagen without reflection, it
+// gives the same failure as Schema.Valid with the AsError option.
+//
+// For every field, actual is the indirect value of the field, it is nil if the field is a nil pointer or
+// a nil slice, pointer reports whether the field is a pointer, nonzero reports whether the indirect
+// value is not the zero value
+type Checker struct {
+	vr  *validator
+	err error
+}
+
+// NewChecker returns a checker of the root schema s, the Ref schemas are resolved by the models of s
+func NewChecker(s *Schema) *Checker {
+	return &Checker{vr: s.newValidator(false, []ValidOption{AsError()})}
+}
+
+// Stopped reports whether the walking should stop
+func (c *Checker) Stopped() bool {
+	return c.err != nil || c.vr.stopped()
+}
+
+// Field returns the path of the property of the parent field
+func (c *Checker) Field(parent, property string) string {
+	return initFieldName(parent, property)
+}
+
+// Item returns the path of the item of the parent field
+func (c *Checker) Item(parent string, idx int) string {
+	return initItemName(parent, idx)
+}
+
+// Value checks the rules of a String, Number, Boolean or File field
+func (c *Checker) Value(s *Schema, field string, actual interface{}, pointer, nonzero bool) {
+	c.check(s, field, actual, 0, pointer, nonzero)
+}
+
+// Array checks the rules of an Array field and reports whether the items should be checked
+func (c *Checker) Array(s *Schema, field string, actual interface{}, length int, pointer, nonzero bool) bool {
+	return c.check(s, field, actual, length, pointer, nonzero) && s.Items != nil
+}
+
+// Object checks the rules of an Object field and returns the schema of the properties, which is nil if
+// the properties should not be checked, and a function for restoring the modes inherited by the
+// properties. The properties of a nil pointer are checked as the zero value, the same as Schema.Valid
+func (c *Checker) Object(s *Schema, field string, actual interface{}, pointer, nonzero bool) (properties *Schema, restore func()) {
+	restore = func() {}
+	if s.Ref != "" {
+		ms, walk, end := c.vr.refSchema(s, actual == nil)
+		properties, restoreModes := c.Object(ms, field, actual, pointer, nonzero)
+		if properties == nil || !walk {
+			restoreModes()
+			end()
+			return nil, restore
+		}
+		return properties, func() {
+			restoreModes()
+			end()
+		}
+	}
+	if !c.check(s, field, actual, 0, pointer, nonzero) {
+		return nil, restore
+	}
+	if s.Validations != nil {
+		restore = c.vr.inherit(s.Validations)
+	}
+	if field != "" && c.vr.getRequiredMode(s) == RequiredPresent && actual == nil {
+		// the properties of an absent object are not required
+		restore()
+		return nil, func() {}
+	}
+	return s, restore
+}
+
+// ValidSchema calls the SchemaValidator of the struct pointer v after the rules of its fields pass, v
+// should not be nil
+func (c *Checker) ValidSchema(s *Schema, field string, v interface{}) {
+	sv, ok := v.(SchemaValidator)
+	if !ok || c.Stopped() {
+		return
+	}
+	if err := sv.ValidSchema(); err != nil {
+		c.vr.failSchema(s, field, err, reflect.Indirect(reflect.ValueOf(v)).Interface())
+	}
+}
+
+// Err returns the first failure as a *ValidationError, or the error of validating
+func (c *Checker) Err() error {
+	if c.err != nil {
+		return c.err
+	}
+	if len(c.vr.failures) > 0 {
+		return c.vr.failures[0]
+	}
+	return nil
+}
+
+func (c *Checker) check(s *Schema, field string, actual interface{}, length int, pointer, nonzero bool) bool {
+	if c.err != nil {
+		return false
+	}
+	if s.Validations == nil {
+		return true
+	}
+	ok, err := s.check(c.vr, field, actual, length, c.supplied(s, actual, pointer, nonzero))
+	if err != nil {
+		c.err = err
+		return false
+	}
+	return ok
+}
+
+// supplied is the same as validator.supplied without presence
+func (c *Checker) supplied(s *Schema, actual interface{}, pointer, nonzero bool) bool {
+	if c.vr.getRequiredMode(s) == RequiredPresent {
+		return actual != nil && (pointer || nonzero)
+	}
+	return nonzero
+}
@@ -0,0 +1,66 @@
+// Copyright 2020 orivil.com. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found at https://mit-license.org.
+
+// Package corpus holds the models shared by the tests of the generated validators, the generated
+// Validate methods must give the same failures as Schema.Valid
+package corpus
+
+import (
+	"errors"
+	"github.com/orivil/schema"
+)
+
+//go:generate go run ../../cmd/schemagen
+
+//schema:generate
+type User struct {
+	Name    string   `json:"name" schema:"required;maxLen:4;lenUnit:rune" desc:"user name"`
+	Email   *string  `json:"email" schema:"required:present;format:email"`
+	Age     int      `json:"age" schema:"minNum:18;maxNum:120"`
+	Score   *float64 `json:"score" schema:"minExcNum:0;msg:{{.Name}} must be positive"`
+	Terms   bool     `json:"terms" schema:"const:true"`
+	Role    string   `json:"role" schema:"enum:admin,user"`
+	Tags    []string `json:"tags" schema:"minItems:1;maxItems:3"`
+	Address *Address `json:"address" schema:"required"`
+	Profile Profile  `json:"profile" schema:"lenUnit:byte"`
+	Friends []*User  `json:"friends" schema:"maxItems:2"`
+	Secret  string   `json:"-"`
+	Codes   []int    `json:"codes"`
+}
+
+//schema:generate
+type Address struct {
+	City  string `json:"city" schema:"required" desc:"city"`
+	Zip   string `json:"zip" schema:"pattern:^\\d{6}$"`
+	Owner *User  `json:"owner"`
+}
+
+// ValidSchema implements schema.SchemaValidator by the pointer receiver
+func (a *Address) ValidSchema() error {
+	if a.City == "Nowhere" {
+		return errors.New("unknown city")
+	}
+	return nil
+}
+
+//schema:generate
+type Profile struct {
+	Bio      string   `json:"bio" schema:"maxLen:4"`
+	Site     string   `json:"site" schema:"format:uri"`
+	Contacts *Contact `json:"contacts" schema:"required:present"`
+}
+
+//schema:generate
+type Contact struct {
+	Phone *string `json:"phone" schema:"required:present;minLen:3"`
+	QQ    string  `json:"qq"`
+}
+
+// ValidSchema implements schema.SchemaValidator by the value receiver
+func (c Contact) ValidSchema() error {
+	if c.QQ == "10000" {
+		return &schema.ValidationError{Field: "qq", Rule: "reserved", Message: "{{.Field}} is reserved"}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+// Copyright 2020 orivil.com. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found at https://mit-license.org.
+
+package corpus
+
+import (
+	"encoding/json"
+	"github.com/orivil/schema"
+	"testing"
+)
+
+func newStr(s string) *string     { return &s }
+func newFloat(f float64) *float64 { return &f }
+
+// validUser returns a user which passes all the rules
+func validUser() *User {
+	return &User{
+		Name:    "Nina",
+		Email:   newStr("nina@example.com"),
+		Age:     18,
+		Score:   newFloat(1),
+		Terms:   true,
+		Role:    "admin",
+		Tags:    []string{"a"},
+		Address: &Address{City: "Chengdu", Zip: "610000"},
+		Profile: Profile{Bio: "bio", Contacts: &Contact{Phone: newStr("123")}},
+	}
+}
+
+// corpus returns the users which pass or fail the rules one by one
+func corpus() []*User {
+	modify := func(f func(u *User)) *User {
+		u := validUser()
+		f(u)
+		return u
+	}
+	return []*User{
+		validUser(),
+		{},
+		modify(func(u *User) { u.Name = "" }),
+		modify(func(u *User) { u.Name = "éééé" }),
+		modify(func(u *User) { u.Name = "Ninaa" }),
+		modify(func(u *User) { u.Email = nil }),
+		modify(func(u *User) { u.Email = newStr("") }),
+		modify(func(u *User) { u.Email = newStr("nina") }),
+		modify(func(u *User) { u.Age = 0 }),
+		modify(func(u *User) { u.Age = 17 }),
+		modify(func(u *User) { u.Age = 121 }),
+		modify(func(u *User) { u.Score = newFloat(0) }),
+		modify(func(u *User) { u.Score = newFloat(-1) }),
+		modify(func(u *User) { u.Score = nil }),
+		modify(func(u *User) { u.Terms = false }),
+		modify(func(u *User) { u.Role = "guest" }),
+		modify(func(u *User) { u.Tags = nil }),
+		modify(func(u *User) { u.Tags = []string{} }),
+		modify(func(u *User) { u.Tags = []string{"a", "b", "c", "d"} }),
+		modify(func(u *User) { u.Address = nil }),
+		modify(func(u *User) { u.Address = &Address{} }),
+		modify(func(u *User) { u.Address.Zip = "6100" }),
+		modify(func(u *User) { u.Address.Owner = &User{Name: "Jay"} }),
+		modify(func(u *User) { u.Address.Owner = validUser() }),
+		modify(func(u *User) { u.Profile = Profile{} }),
+		modify(func(u *User) { u.Profile.Bio = "ééé" }),
+		modify(func(u *User) { u.Profile.Site = "example.com" }),
+		modify(func(u *User) { u.Profile.Contacts = nil }),
+		modify(func(u *User) { u.Profile.Contacts.Phone = nil }),
+		modify(func(u *User) { u.Profile.Contacts.Phone = newStr("12") }),
+		modify(func(u *User) { u.Friends = []*User{validUser(), nil} }),
+		modify(func(u *User) { u.Friends = []*User{validUser(), {Name: "Jay"}} }),
+		modify(func(u *User) { u.Friends = []*User{nil, nil, nil} }),
+		modify(func(u *User) { u.Secret = "secret"; u.Codes = []int{1} }),
+		modify(func(u *User) { u.Address.City = "Nowhere" }),
+		modify(func(u *User) { u.Address.City = "Nowhere"; u.Address.Zip = "6100" }),
+		modify(func(u *User) { u.Profile.Contacts.QQ = "10000" }),
+		modify(func(u *User) { u.Profile.Contacts = &Contact{QQ: "10000"} }),
+		modify(func(u *User) { u.Friends = []*User{modify(func(f *User) { f.Address.City = "Nowhere" })} }),
+	}
+}
+
+func TestGeneratedSchema(t *testing.T) {
+	// the schema is built by reflection since User implements schema.Decoder by the pointer receiver
+	need, err := schema.NewSchema(User{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := new(User).Schema()
+	if jsonStr(got) != jsonStr(need) {
+		t.Fatalf("need: %s\ngot: %s", jsonStr(need), jsonStr(got))
+	}
+	for ref := range need.Models() {
+		if got.Models().GetSchema(ref) == nil {
+			t.Errorf("model %s is not registered", ref)
+		}
+	}
+}
+
+func TestGeneratedValidate(t *testing.T) {
+	s, err := schema.NewSchema(User{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	failures := 0
+	for i, u := range corpus() {
+		_, need := s.Valid(u, schema.AsError())
+		got := u.Validate()
+		if errorStr(got) != errorStr(need) {
+			t.Errorf("case %d need: %s\ngot: %s", i, errorStr(need), errorStr(got))
+		}
+		if need != nil {
+			failures++
+		}
+	}
+	if failures < 25 {
+		t.Fatalf("need the corpus to cover the rules, got %d failures", failures)
+	}
+	// the structs could be validated alone
+	ps, err := schema.NewSchema(Profile{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range []*Profile{{}, {Contacts: &Contact{}}, {Bio: "bio", Contacts: &Contact{Phone: newStr("123")}}} {
+		_, need := ps.Valid(p, schema.AsError())
+		got := p.Validate()
+		if errorStr(got) != errorStr(need) {
+			t.Errorf("profile case %d need: %s\ngot: %s", i, errorStr(need), errorStr(got))
+		}
+	}
+}
+
+func errorStr(err error) string {
+	if err == nil {
+		return "<nil>"
+	}
+	e, ok := err.(*schema.ValidationError)
+	if !ok {
+		return "unexpected error: " + err.Error()
+	}
+	return e.Error() + "; " + e.Localize(schema.LocaleEnUS)
+}
+
+func jsonStr(v interface{}) string {
+	data, err := json.MarshalIndent(v, "", "\t")
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
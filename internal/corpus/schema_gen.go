@@ -0,0 +1,531 @@
+// Code generated by schemagen. DO NOT EDIT.
+
+package corpus
+
+import "github.com/orivil/schema"
+
+var _User_schema = new(User).Schema()
+
+// Schema implements schema.Decoder
+func (x *User) Schema() *schema.Schema {
+	ms := make(schema.Models)
+	s1 := &schema.Schema{Model: "User", Namespace: "github.com/orivil/schema/internal/corpus", Type: schema.Object}
+	ms["github.com/orivil/schema/internal/corpus.User"] = s1
+	s1.Properties = schema.Properties{}
+	s2 := &schema.Schema{Type: schema.String}
+	s1.Properties = append(s1.Properties, schema.MustProperty(s2, "name", `json:"name" schema:"required;maxLen:4;lenUnit:rune" desc:"user name"`))
+	s3 := &schema.Schema{Type: schema.String}
+	s1.Properties = append(s1.Properties, schema.MustProperty(s3, "email", `json:"email" schema:"required:present;format:email"`))
+	s4 := &schema.Schema{Type: schema.Number}
+	s1.Properties = append(s1.Properties, schema.MustProperty(s4, "age", `json:"age" schema:"minNum:18;maxNum:120"`))
+	s5 := &schema.Schema{Type: schema.Number}
+	s1.Properties = append(s1.Properties, schema.MustProperty(s5, "score", `json:"score" schema:"minExcNum:0;msg:{{.Name}} must be positive"`))
+	s6 := &schema.Schema{Type: schema.Bool}
+	s1.Properties = append(s1.Properties, schema.MustProperty(s6, "terms", `json:"terms" schema:"const:true"`))
+	s7 := &schema.Schema{Type: schema.String}
+	s1.Properties = append(s1.Properties, schema.MustProperty(s7, "role", `json:"role" schema:"enum:admin,user"`))
+	s9 := &schema.Schema{Type: schema.String}
+	s8 := &schema.Schema{Type: schema.Array, Items: s9}
+	s1.Properties = append(s1.Properties, schema.MustProperty(s8, "tags", `json:"tags" schema:"minItems:1;maxItems:3"`))
+	s10 := &schema.Schema{Model: "Address", Namespace: "github.com/orivil/schema/internal/corpus", Type: schema.Object}
+	ms["github.com/orivil/schema/internal/corpus.Address"] = s10
+	s10.Properties = schema.Properties{}
+	s11 := &schema.Schema{Type: schema.String}
+	s10.Properties = append(s10.Properties, schema.MustProperty(s11, "city", `json:"city" schema:"required" desc:"city"`))
+	s12 := &schema.Schema{Type: schema.String}
+	s10.Properties = append(s10.Properties, schema.MustProperty(s12, "zip", `json:"zip" schema:"pattern:^\\d{6}$"`))
+	s13 := &schema.Schema{Ref: "github.com/orivil/schema/internal/corpus.User"}
+	s10.Properties = append(s10.Properties, schema.MustProperty(s13, "owner", `json:"owner"`))
+	s1.Properties = append(s1.Properties, schema.MustProperty(s10, "address", `json:"address" schema:"required"`))
+	s14 := &schema.Schema{Model: "Profile", Namespace: "github.com/orivil/schema/internal/corpus", Type: schema.Object}
+	ms["github.com/orivil/schema/internal/corpus.Profile"] = s14
+	s14.Properties = schema.Properties{}
+	s15 := &schema.Schema{Type: schema.String}
+	s14.Properties = append(s14.Properties, schema.MustProperty(s15, "bio", `json:"bio" schema:"maxLen:4"`))
+	s16 := &schema.Schema{Type: schema.String}
+	s14.Properties = append(s14.Properties, schema.MustProperty(s16, "site", `json:"site" schema:"format:uri"`))
+	s17 := &schema.Schema{Model: "Contact", Namespace: "github.com/orivil/schema/internal/corpus", Type: schema.Object}
+	ms["github.com/orivil/schema/internal/corpus.Contact"] = s17
+	s17.Properties = schema.Properties{}
+	s18 := &schema.Schema{Type: schema.String}
+	s17.Properties = append(s17.Properties, schema.MustProperty(s18, "phone", `json:"phone" schema:"required:present;minLen:3"`))
+	s19 := &schema.Schema{Type: schema.String}
+	s17.Properties = append(s17.Properties, schema.MustProperty(s19, "qq", `json:"qq"`))
+	s14.Properties = append(s14.Properties, schema.MustProperty(s17, "contacts", `json:"contacts" schema:"required:present"`))
+	s1.Properties = append(s1.Properties, schema.MustProperty(s14, "profile", `json:"profile" schema:"lenUnit:byte"`))
+	s21 := &schema.Schema{Ref: "github.com/orivil/schema/internal/corpus.User"}
+	s20 := &schema.Schema{Type: schema.Array, Items: s21}
+	s1.Properties = append(s1.Properties, schema.MustProperty(s20, "friends", `json:"friends" schema:"maxItems:2"`))
+	s23 := &schema.Schema{Type: schema.Number}
+	s22 := &schema.Schema{Type: schema.Array, Items: s23}
+	s1.Properties = append(s1.Properties, schema.MustProperty(s22, "codes", `json:"codes"`))
+	return s1.WithModels(ms)
+}
+
+// Validate validates x without reflection, it returns the same failure as Schema.Valid with the
+// AsError option
+func (x *User) Validate() error {
+	v := x
+	if v == nil {
+		v = new(User)
+	}
+	s := _User_schema
+	c := schema.NewChecker(s)
+	if ps, restore := c.Object(s, "", *v, true, !v.isZeroSchema()); ps != nil {
+		v.validateSchema(c, ps, "")
+		if x != nil {
+			c.ValidSchema(ps, "", x)
+		}
+		restore()
+	}
+	return c.Err()
+}
+
+func (x *User) validateSchema(c *schema.Checker, s *schema.Schema, field string) {
+	{
+		name := c.Field(field, "name")
+		c.Value(s.Properties[0], name, x.Name, false, x.Name != "")
+		if c.Stopped() {
+			return
+		}
+	}
+	{
+		name := c.Field(field, "email")
+		{
+			var actual interface{}
+			if x.Email != nil {
+				actual = *x.Email
+			}
+			c.Value(s.Properties[1], name, actual, true, x.Email != nil && *x.Email != "")
+		}
+		if c.Stopped() {
+			return
+		}
+	}
+	{
+		name := c.Field(field, "age")
+		c.Value(s.Properties[2], name, x.Age, false, x.Age != 0)
+		if c.Stopped() {
+			return
+		}
+	}
+	{
+		name := c.Field(field, "score")
+		{
+			var actual interface{}
+			if x.Score != nil {
+				actual = *x.Score
+			}
+			c.Value(s.Properties[3], name, actual, true, x.Score != nil && *x.Score != 0)
+		}
+		if c.Stopped() {
+			return
+		}
+	}
+	{
+		name := c.Field(field, "terms")
+		c.Value(s.Properties[4], name, x.Terms, false, x.Terms)
+		if c.Stopped() {
+			return
+		}
+	}
+	{
+		name := c.Field(field, "role")
+		c.Value(s.Properties[5], name, x.Role, false, x.Role != "")
+		if c.Stopped() {
+			return
+		}
+	}
+	{
+		name := c.Field(field, "tags")
+		var actual interface{}
+		if x.Tags != nil {
+			actual = x.Tags
+		}
+		if items := s.Properties[6]; c.Array(items, name, actual, len(x.Tags), false, x.Tags != nil) {
+			for i, item := range x.Tags {
+				c.Value(items.Items, c.Item(name, i), item, false, item != "")
+				if c.Stopped() {
+					return
+				}
+			}
+		}
+		if c.Stopped() {
+			return
+		}
+	}
+	{
+		name := c.Field(field, "address")
+		{
+			var actual interface{}
+			if x.Address != nil {
+				actual = *x.Address
+			}
+			if ps, restore := c.Object(s.Properties[7], name, actual, true, x.Address != nil && !x.Address.isZeroSchema()); ps != nil {
+				v := x.Address
+				if v == nil {
+					v = new(Address)
+				}
+				v.validateSchema(c, ps, name)
+				if x.Address != nil {
+					c.ValidSchema(ps, name, v)
+				}
+				restore()
+			}
+		}
+		if c.Stopped() {
+			return
+		}
+	}
+	{
+		name := c.Field(field, "profile")
+		if ps, restore := c.Object(s.Properties[8], name, x.Profile, false, !x.Profile.isZeroSchema()); ps != nil {
+			x.Profile.validateSchema(c, ps, name)
+			c.ValidSchema(ps, name, &x.Profile)
+			restore()
+		}
+		if c.Stopped() {
+			return
+		}
+	}
+	{
+		name := c.Field(field, "friends")
+		var actual interface{}
+		if x.Friends != nil {
+			actual = x.Friends
+		}
+		if items := s.Properties[9]; c.Array(items, name, actual, len(x.Friends), false, x.Friends != nil) {
+			for i, item := range x.Friends {
+				{
+					var actual interface{}
+					if item != nil {
+						actual = *item
+					}
+					if ps, restore := c.Object(items.Items, c.Item(name, i), actual, true, item != nil && !item.isZeroSchema()); ps != nil {
+						v := item
+						if v == nil {
+							v = new(User)
+						}
+						v.validateSchema(c, ps, c.Item(name, i))
+						if item != nil {
+							c.ValidSchema(ps, c.Item(name, i), v)
+						}
+						restore()
+					}
+				}
+				if c.Stopped() {
+					return
+				}
+			}
+		}
+		if c.Stopped() {
+			return
+		}
+	}
+	{
+		name := c.Field(field, "codes")
+		var actual interface{}
+		if x.Codes != nil {
+			actual = x.Codes
+		}
+		if items := s.Properties[10]; c.Array(items, name, actual, len(x.Codes), false, x.Codes != nil) {
+			for i, item := range x.Codes {
+				c.Value(items.Items, c.Item(name, i), item, false, item != 0)
+				if c.Stopped() {
+					return
+				}
+			}
+		}
+		if c.Stopped() {
+			return
+		}
+	}
+}
+
+func (x *User) isZeroSchema() bool {
+	return x.Name == "" &&
+		x.Email == nil &&
+		x.Age == 0 &&
+		x.Score == nil &&
+		!x.Terms &&
+		x.Role == "" &&
+		x.Tags == nil &&
+		x.Address == nil &&
+		x.Profile.isZeroSchema() &&
+		x.Friends == nil &&
+		x.Secret == "" &&
+		x.Codes == nil
+}
+
+var _Address_schema = new(Address).Schema()
+
+// Schema implements schema.Decoder
+func (x *Address) Schema() *schema.Schema {
+	ms := make(schema.Models)
+	s1 := &schema.Schema{Model: "Address", Namespace: "github.com/orivil/schema/internal/corpus", Type: schema.Object}
+	ms["github.com/orivil/schema/internal/corpus.Address"] = s1
+	s1.Properties = schema.Properties{}
+	s2 := &schema.Schema{Type: schema.String}
+	s1.Properties = append(s1.Properties, schema.MustProperty(s2, "city", `json:"city" schema:"required" desc:"city"`))
+	s3 := &schema.Schema{Type: schema.String}
+	s1.Properties = append(s1.Properties, schema.MustProperty(s3, "zip", `json:"zip" schema:"pattern:^\\d{6}$"`))
+	s4 := &schema.Schema{Model: "User", Namespace: "github.com/orivil/schema/internal/corpus", Type: schema.Object}
+	ms["github.com/orivil/schema/internal/corpus.User"] = s4
+	s4.Properties = schema.Properties{}
+	s5 := &schema.Schema{Type: schema.String}
+	s4.Properties = append(s4.Properties, schema.MustProperty(s5, "name", `json:"name" schema:"required;maxLen:4;lenUnit:rune" desc:"user name"`))
+	s6 := &schema.Schema{Type: schema.String}
+	s4.Properties = append(s4.Properties, schema.MustProperty(s6, "email", `json:"email" schema:"required:present;format:email"`))
+	s7 := &schema.Schema{Type: schema.Number}
+	s4.Properties = append(s4.Properties, schema.MustProperty(s7, "age", `json:"age" schema:"minNum:18;maxNum:120"`))
+	s8 := &schema.Schema{Type: schema.Number}
+	s4.Properties = append(s4.Properties, schema.MustProperty(s8, "score", `json:"score" schema:"minExcNum:0;msg:{{.Name}} must be positive"`))
+	s9 := &schema.Schema{Type: schema.Bool}
+	s4.Properties = append(s4.Properties, schema.MustProperty(s9, "terms", `json:"terms" schema:"const:true"`))
+	s10 := &schema.Schema{Type: schema.String}
+	s4.Properties = append(s4.Properties, schema.MustProperty(s10, "role", `json:"role" schema:"enum:admin,user"`))
+	s12 := &schema.Schema{Type: schema.String}
+	s11 := &schema.Schema{Type: schema.Array, Items: s12}
+	s4.Properties = append(s4.Properties, schema.MustProperty(s11, "tags", `json:"tags" schema:"minItems:1;maxItems:3"`))
+	s13 := &schema.Schema{Ref: "github.com/orivil/schema/internal/corpus.Address"}
+	s4.Properties = append(s4.Properties, schema.MustProperty(s13, "address", `json:"address" schema:"required"`))
+	s14 := &schema.Schema{Model: "Profile", Namespace: "github.com/orivil/schema/internal/corpus", Type: schema.Object}
+	ms["github.com/orivil/schema/internal/corpus.Profile"] = s14
+	s14.Properties = schema.Properties{}
+	s15 := &schema.Schema{Type: schema.String}
+	s14.Properties = append(s14.Properties, schema.MustProperty(s15, "bio", `json:"bio" schema:"maxLen:4"`))
+	s16 := &schema.Schema{Type: schema.String}
+	s14.Properties = append(s14.Properties, schema.MustProperty(s16, "site", `json:"site" schema:"format:uri"`))
+	s17 := &schema.Schema{Model: "Contact", Namespace: "github.com/orivil/schema/internal/corpus", Type: schema.Object}
+	ms["github.com/orivil/schema/internal/corpus.Contact"] = s17
+	s17.Properties = schema.Properties{}
+	s18 := &schema.Schema{Type: schema.String}
+	s17.Properties = append(s17.Properties, schema.MustProperty(s18, "phone", `json:"phone" schema:"required:present;minLen:3"`))
+	s19 := &schema.Schema{Type: schema.String}
+	s17.Properties = append(s17.Properties, schema.MustProperty(s19, "qq", `json:"qq"`))
+	s14.Properties = append(s14.Properties, schema.MustProperty(s17, "contacts", `json:"contacts" schema:"required:present"`))
+	s4.Properties = append(s4.Properties, schema.MustProperty(s14, "profile", `json:"profile" schema:"lenUnit:byte"`))
+	s21 := &schema.Schema{Ref: "github.com/orivil/schema/internal/corpus.User"}
+	s20 := &schema.Schema{Type: schema.Array, Items: s21}
+	s4.Properties = append(s4.Properties, schema.MustProperty(s20, "friends", `json:"friends" schema:"maxItems:2"`))
+	s23 := &schema.Schema{Type: schema.Number}
+	s22 := &schema.Schema{Type: schema.Array, Items: s23}
+	s4.Properties = append(s4.Properties, schema.MustProperty(s22, "codes", `json:"codes"`))
+	s1.Properties = append(s1.Properties, schema.MustProperty(s4, "owner", `json:"owner"`))
+	return s1.WithModels(ms)
+}
+
+// Validate validates x without reflection, it returns the same failure as Schema.Valid with the
+// AsError option
+func (x *Address) Validate() error {
+	v := x
+	if v == nil {
+		v = new(Address)
+	}
+	s := _Address_schema
+	c := schema.NewChecker(s)
+	if ps, restore := c.Object(s, "", *v, true, !v.isZeroSchema()); ps != nil {
+		v.validateSchema(c, ps, "")
+		if x != nil {
+			c.ValidSchema(ps, "", x)
+		}
+		restore()
+	}
+	return c.Err()
+}
+
+func (x *Address) validateSchema(c *schema.Checker, s *schema.Schema, field string) {
+	{
+		name := c.Field(field, "city")
+		c.Value(s.Properties[0], name, x.City, false, x.City != "")
+		if c.Stopped() {
+			return
+		}
+	}
+	{
+		name := c.Field(field, "zip")
+		c.Value(s.Properties[1], name, x.Zip, false, x.Zip != "")
+		if c.Stopped() {
+			return
+		}
+	}
+	{
+		name := c.Field(field, "owner")
+		{
+			var actual interface{}
+			if x.Owner != nil {
+				actual = *x.Owner
+			}
+			if ps, restore := c.Object(s.Properties[2], name, actual, true, x.Owner != nil && !x.Owner.isZeroSchema()); ps != nil {
+				v := x.Owner
+				if v == nil {
+					v = new(User)
+				}
+				v.validateSchema(c, ps, name)
+				if x.Owner != nil {
+					c.ValidSchema(ps, name, v)
+				}
+				restore()
+			}
+		}
+		if c.Stopped() {
+			return
+		}
+	}
+}
+
+func (x *Address) isZeroSchema() bool {
+	return x.City == "" &&
+		x.Zip == "" &&
+		x.Owner == nil
+}
+
+var _Profile_schema = new(Profile).Schema()
+
+// Schema implements schema.Decoder
+func (x *Profile) Schema() *schema.Schema {
+	ms := make(schema.Models)
+	s1 := &schema.Schema{Model: "Profile", Namespace: "github.com/orivil/schema/internal/corpus", Type: schema.Object}
+	ms["github.com/orivil/schema/internal/corpus.Profile"] = s1
+	s1.Properties = schema.Properties{}
+	s2 := &schema.Schema{Type: schema.String}
+	s1.Properties = append(s1.Properties, schema.MustProperty(s2, "bio", `json:"bio" schema:"maxLen:4"`))
+	s3 := &schema.Schema{Type: schema.String}
+	s1.Properties = append(s1.Properties, schema.MustProperty(s3, "site", `json:"site" schema:"format:uri"`))
+	s4 := &schema.Schema{Model: "Contact", Namespace: "github.com/orivil/schema/internal/corpus", Type: schema.Object}
+	ms["github.com/orivil/schema/internal/corpus.Contact"] = s4
+	s4.Properties = schema.Properties{}
+	s5 := &schema.Schema{Type: schema.String}
+	s4.Properties = append(s4.Properties, schema.MustProperty(s5, "phone", `json:"phone" schema:"required:present;minLen:3"`))
+	s6 := &schema.Schema{Type: schema.String}
+	s4.Properties = append(s4.Properties, schema.MustProperty(s6, "qq", `json:"qq"`))
+	s1.Properties = append(s1.Properties, schema.MustProperty(s4, "contacts", `json:"contacts" schema:"required:present"`))
+	return s1.WithModels(ms)
+}
+
+// Validate validates x without reflection, it returns the same failure as Schema.Valid with the
+// AsError option
+func (x *Profile) Validate() error {
+	v := x
+	if v == nil {
+		v = new(Profile)
+	}
+	s := _Profile_schema
+	c := schema.NewChecker(s)
+	if ps, restore := c.Object(s, "", *v, true, !v.isZeroSchema()); ps != nil {
+		v.validateSchema(c, ps, "")
+		if x != nil {
+			c.ValidSchema(ps, "", x)
+		}
+		restore()
+	}
+	return c.Err()
+}
+
+func (x *Profile) validateSchema(c *schema.Checker, s *schema.Schema, field string) {
+	{
+		name := c.Field(field, "bio")
+		c.Value(s.Properties[0], name, x.Bio, false, x.Bio != "")
+		if c.Stopped() {
+			return
+		}
+	}
+	{
+		name := c.Field(field, "site")
+		c.Value(s.Properties[1], name, x.Site, false, x.Site != "")
+		if c.Stopped() {
+			return
+		}
+	}
+	{
+		name := c.Field(field, "contacts")
+		{
+			var actual interface{}
+			if x.Contacts != nil {
+				actual = *x.Contacts
+			}
+			if ps, restore := c.Object(s.Properties[2], name, actual, true, x.Contacts != nil && !x.Contacts.isZeroSchema()); ps != nil {
+				v := x.Contacts
+				if v == nil {
+					v = new(Contact)
+				}
+				v.validateSchema(c, ps, name)
+				if x.Contacts != nil {
+					c.ValidSchema(ps, name, v)
+				}
+				restore()
+			}
+		}
+		if c.Stopped() {
+			return
+		}
+	}
+}
+
+func (x *Profile) isZeroSchema() bool {
+	return x.Bio == "" &&
+		x.Site == "" &&
+		x.Contacts == nil
+}
+
+var _Contact_schema = new(Contact).Schema()
+
+// Schema implements schema.Decoder
+func (x *Contact) Schema() *schema.Schema {
+	ms := make(schema.Models)
+	s1 := &schema.Schema{Model: "Contact", Namespace: "github.com/orivil/schema/internal/corpus", Type: schema.Object}
+	ms["github.com/orivil/schema/internal/corpus.Contact"] = s1
+	s1.Properties = schema.Properties{}
+	s2 := &schema.Schema{Type: schema.String}
+	s1.Properties = append(s1.Properties, schema.MustProperty(s2, "phone", `json:"phone" schema:"required:present;minLen:3"`))
+	s3 := &schema.Schema{Type: schema.String}
+	s1.Properties = append(s1.Properties, schema.MustProperty(s3, "qq", `json:"qq"`))
+	return s1.WithModels(ms)
+}
+
+// Validate validates x without reflection, it returns the same failure as Schema.Valid with the
+// AsError option
+func (x *Contact) Validate() error {
+	v := x
+	if v == nil {
+		v = new(Contact)
+	}
+	s := _Contact_schema
+	c := schema.NewChecker(s)
+	if ps, restore := c.Object(s, "", *v, true, !v.isZeroSchema()); ps != nil {
+		v.validateSchema(c, ps, "")
+		if x != nil {
+			c.ValidSchema(ps, "", x)
+		}
+		restore()
+	}
+	return c.Err()
+}
+
+func (x *Contact) validateSchema(c *schema.Checker, s *schema.Schema, field string) {
+	{
+		name := c.Field(field, "phone")
+		{
+			var actual interface{}
+			if x.Phone != nil {
+				actual = *x.Phone
+			}
+			c.Value(s.Properties[0], name, actual, true, x.Phone != nil && *x.Phone != "")
+		}
+		if c.Stopped() {
+			return
+		}
+	}
+	{
+		name := c.Field(field, "qq")
+		c.Value(s.Properties[1], name, x.QQ, false, x.QQ != "")
+		if c.Stopped() {
+			return
+		}
+	}
+}
+
+func (x *Contact) isZeroSchema() bool {
+	return x.Phone == nil &&
+		x.QQ == ""
+}
@@ -0,0 +1,297 @@
+// Copyright 2020 orivil.com. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found at https://mit-license.org.
+
+package schema
+
+import (
+	"encoding/json"
+	"github.com/orivil/types"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const JSONSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema converts the schema to a JSON Schema (draft 2020-12) document, every model is exported
+// to "$defs" and referenced by "$ref", the rules given to a model by a property are exported next to the
+// "$ref" by "allOf", the rules without a JSON Schema keyword, such as "use" and the comparisons of the
+// fields, are not exported
+func (s *Schema) JSONSchema() map[string]interface{} {
+	e := &jsonSchemaExporter{defs: make(map[string]interface{}), refPrefix: "#/$defs/", models: s.models}
+	doc := e.export(s)
+	doc["$schema"] = JSONSchemaDraft
+	if len(e.defs) > 0 {
+		doc["$defs"] = e.defs
+	}
+	return doc
+}
+
+// MarshalJSONSchema returns the JSON encoding of Schema.JSONSchema
+func (s *Schema) MarshalJSONSchema() ([]byte, error) {
+	return json.Marshal(s.JSONSchema())
+}
+
+type jsonSchemaExporter struct {
+	defs      map[string]interface{}
+	refPrefix string
+	// names holds the definition names of models, the reference name of a model is used if not found
+	names map[string]string
+	// models holds the models without the rules of the properties, see propertyOf
+	models Models
+}
+
+func (e *jsonSchemaExporter) defName(ref string) string {
+	if name, ok := e.names[ref]; ok {
+		return name
+	}
+	return ref
+}
+
+func (e *jsonSchemaExporter) ref(name string) map[string]interface{} {
+	name = strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+	return map[string]interface{}{"$ref": e.refPrefix + name}
+}
+
+func (e *jsonSchemaExporter) export(s *Schema) map[string]interface{} {
+	var node map[string]interface{}
+	if s.Ref != "" {
+		node = e.useModel(e.defName(s.Ref), s, e.models.GetSchema(s.Ref))
+	} else if s.Model != "" {
+		ref := modelRef(s.Namespace, s.Model)
+		name := e.defName(ref)
+		model := e.models.GetSchema(ref)
+		if model == nil {
+			model = s
+		}
+		if _, ok := e.defs[name]; !ok {
+			// placeholder stops the recursion of self-referencing models
+			e.defs[name] = nil
+			e.defs[name] = e.exportType(model)
+		}
+		node = e.useModel(name, s, model)
+	} else {
+		node = e.exportType(s)
+	}
+	if s.Description != "" {
+		node["description"] = s.Description
+	}
+	return node
+}
+
+// useModel returns the reference of the model definition, the rules which the property s gives to the
+// model are exported with the reference by "allOf"
+func (e *jsonSchemaExporter) useModel(name string, s, model *Schema) map[string]interface{} {
+	node := e.ref(name)
+	if s == model || s.Validations == nil {
+		return node
+	}
+	kind := s.Type
+	var modelRules map[string]interface{}
+	if model != nil {
+		kind = model.Type
+		modelRules = e.exportType(&Schema{Type: kind, Validations: model.Validations})
+	}
+	rules := e.exportType(&Schema{Type: kind, Validations: s.Validations})
+	for key, value := range rules {
+		if key == "type" || reflect.DeepEqual(value, modelRules[key]) {
+			delete(rules, key)
+		}
+	}
+	if len(rules) == 0 {
+		return node
+	}
+	return map[string]interface{}{"allOf": []interface{}{node, rules}}
+}
+
+func (e *jsonSchemaExporter) exportType(s *Schema) map[string]interface{} {
+	node := make(map[string]interface{})
+	vs := s.Validations
+	if vs == nil {
+		vs = &Validations{}
+	}
+	switch s.Type {
+	case String:
+		node["type"] = "string"
+		if vs.MinLen != nil {
+			node["minLength"] = *vs.MinLen
+		}
+		if vs.MaxLen != nil {
+			node["maxLength"] = *vs.MaxLen
+		}
+		if vs.Pattern != "" {
+			node["pattern"] = vs.Pattern
+		}
+		if vs.Format != "" {
+			node["format"] = vs.Format
+		}
+		if vs.Enum != nil {
+			node["enum"] = vs.Enum
+		}
+	case Number:
+		node["type"] = "number"
+		if vs.Integer {
+			node["type"] = "integer"
+		}
+		if vs.MinNum != nil {
+			node["minimum"] = *vs.MinNum
+		}
+		if vs.MaxNum != nil {
+			node["maximum"] = *vs.MaxNum
+		}
+		if vs.MinExcNum != nil {
+			node["exclusiveMinimum"] = *vs.MinExcNum
+		}
+		if vs.MaxExcNum != nil {
+			node["exclusiveMaximum"] = *vs.MaxExcNum
+		}
+		if vs.Enum != nil {
+			node["enum"] = jsonEnum(Number, vs.Enum)
+		}
+	case Bool:
+		node["type"] = "boolean"
+		if vs.Const != nil {
+			node["const"] = *vs.Const
+		}
+	case File:
+		node["type"] = "string"
+		node["contentMediaType"] = "application/octet-stream"
+		if len(vs.Mime) == 1 && !strings.HasSuffix(vs.Mime[0], "/*") {
+			node["contentMediaType"] = vs.Mime[0]
+		}
+	case Array:
+		node["type"] = "array"
+		if s.Items != nil {
+			node["items"] = e.export(s.Items)
+		}
+		// the length of an array is limited by both minItems and minLen
+		if vs.MinItems != nil {
+			node["minItems"] = *vs.MinItems
+		} else if vs.MinLen != nil {
+			node["minItems"] = *vs.MinLen
+		}
+		if vs.MaxItems != nil {
+			node["maxItems"] = *vs.MaxItems
+		} else if vs.MaxLen != nil {
+			node["maxItems"] = *vs.MaxLen
+		}
+	case Object:
+		node["type"] = "object"
+		properties := make(map[string]interface{}, len(s.Properties))
+		var required []string
+		for _, property := range s.Properties {
+			properties[property.Name] = e.export(property)
+			if property.Validations != nil && property.Validations.Required {
+				required = append(required, property.Name)
+			}
+		}
+		if len(properties) > 0 {
+			node["properties"] = properties
+		}
+		if len(required) > 0 {
+			node["required"] = required
+		}
+		if s.AdditionalProperties != nil {
+			node["additionalProperties"] = e.export(s.AdditionalProperties)
+		} else if vs.Strict {
+			node["additionalProperties"] = false
+		}
+		if vs.MinProps != nil {
+			node["minProperties"] = *vs.MinProps
+		}
+		if vs.MaxProps != nil {
+			node["maxProperties"] = *vs.MaxProps
+		}
+		// the length of the keys is counted by the LenUnit of the map
+		names := make(map[string]interface{})
+		if vs.KeyPattern != "" {
+			names["pattern"] = vs.KeyPattern
+		}
+		if vs.KeyMinLen != nil {
+			names["minLength"] = *vs.KeyMinLen
+		}
+		if vs.KeyMaxLen != nil {
+			names["maxLength"] = *vs.KeyMaxLen
+		}
+		if len(names) > 0 {
+			node["propertyNames"] = names
+		}
+		e.exportConditions(node, s)
+	}
+	return node
+}
+
+// exportConditions exports the conditional rules of the properties, "requiredWith" is exported as
+// "dependentRequired", "requiredIf" and "requiredWithout" are exported as the "if" and "then" schemas
+func (e *jsonSchemaExporter) exportConditions(node map[string]interface{}, s *Schema) {
+	dependent := make(map[string][]string)
+	var conditions []interface{}
+	for _, property := range s.Properties {
+		vs := property.Validations
+		if vs == nil {
+			continue
+		}
+		for _, name := range vs.RequiredWith {
+			dependent[name] = append(dependent[name], property.Name)
+		}
+		then := map[string]interface{}{"required": []string{property.Name}}
+		if c := vs.RequiredIf; c != nil {
+			var kind JsonKind
+			if field := s.Property(c.Field); field != nil {
+				kind = field.Type
+			}
+			conditions = append(conditions, map[string]interface{}{
+				"if": map[string]interface{}{
+					"properties": map[string]interface{}{c.Field: map[string]interface{}{"enum": jsonEnum(kind, c.Values)}},
+					"required":   []string{c.Field},
+				},
+				"then": then,
+			})
+		}
+		if vs.RequiredWithout != nil {
+			conditions = append(conditions, map[string]interface{}{
+				"if":   map[string]interface{}{"not": map[string]interface{}{"required": vs.RequiredWithout}},
+				"then": then,
+			})
+		}
+	}
+	if len(dependent) > 0 {
+		// the nodes only hold the generic maps, which are written by MarshalYAML
+		required := make(map[string]interface{}, len(dependent))
+		for name, names := range dependent {
+			required[name] = names
+		}
+		node["dependentRequired"] = required
+	}
+	if len(conditions) > 0 {
+		node["allOf"] = conditions
+	}
+}
+
+// jsonEnum converts the enum values to the JSON values of the kind
+func jsonEnum(kind JsonKind, values []string) []interface{} {
+	enum := make([]interface{}, len(values))
+	for i, str := range values {
+		enum[i] = str
+		switch kind {
+		case Number:
+			if f64, err := types.String(str).Float64(); err == nil {
+				enum[i] = f64
+			}
+		case Bool:
+			if b, err := strconv.ParseBool(str); err == nil {
+				enum[i] = b
+			}
+		}
+	}
+	return enum
+}
+
+// modelRef returns the reference name of a model, it is the same as Schema.Ref
+func modelRef(namespace, model string) string {
+	if namespace == "" {
+		return model
+	}
+	return namespace + "." + model
+}
@@ -0,0 +1,597 @@
+// Copyright 2020 orivil.com. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found at https://mit-license.org.
+
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type JSONSchemaError struct {
+	Path string
+	Err  string
+}
+
+func (e *JSONSchemaError) Error() string {
+	return fmt.Sprintf("json schema [%s] got error: %s", e.Path, e.Err)
+}
+
+// ParseJSONSchema builds a schema from a JSON Schema document, only local references such as
+// "#/$defs/name", "#/definitions/name" and "#" are supported, a reference to a model which is
+// being resolved becomes a Ref schema, which is resolved through the models of the returned schema
+func ParseJSONSchema(data []byte) (*Schema, error) {
+	var root map[string]interface{}
+	err := json.Unmarshal(data, &root)
+	if err != nil {
+		return nil, err
+	}
+	p := &jsonSchemaParser{root: root, resolving: make(map[string]struct{}), models: make(Models)}
+	s, err := p.parseModel("#", root, "#")
+	if err != nil {
+		return nil, err
+	}
+	return s.WithModels(p.models), nil
+}
+
+type jsonSchemaParser struct {
+	root      map[string]interface{}
+	resolving map[string]struct{}
+	models    Models
+}
+
+var jsonSchemaKinds = map[string]JsonKind{
+	"string":  String,
+	"number":  Number,
+	"integer": Number,
+	"boolean": Bool,
+	"array":   Array,
+	"object":  Object,
+}
+
+// keywords which have no effect on validating
+var jsonSchemaAnnotations = map[string]struct{}{
+	"$schema":  {},
+	"$id":      {},
+	"$comment": {},
+	"title":    {},
+	"default":  {},
+	"examples": {},
+}
+
+func (p *jsonSchemaParser) parseModel(pointer string, node map[string]interface{}, path string) (*Schema, error) {
+	name := p.modelName(pointer)
+	p.resolving[pointer] = struct{}{}
+	defer delete(p.resolving, pointer)
+	s, err := p.parse(node, path)
+	if err != nil {
+		return nil, err
+	}
+	if s.Type == Object && s.Model == "" {
+		s.Model = name
+		p.models[name] = s
+	}
+	return s, nil
+}
+
+func (p *jsonSchemaParser) modelName(pointer string) string {
+	if pointer == "#" {
+		if title, ok := p.root["title"].(string); ok && title != "" {
+			return title
+		}
+		return "root"
+	}
+	name := pointer[strings.LastIndex(pointer, "/")+1:]
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(name)
+}
+
+func (p *jsonSchemaParser) resolve(ref, path string) (*Schema, error) {
+	if _, ok := p.resolving[ref]; ok {
+		return &Schema{Ref: p.modelName(ref)}, nil
+	}
+	if ref == "#" {
+		return p.parseModel(ref, p.root, ref)
+	}
+	var defs string
+	if strings.HasPrefix(ref, "#/$defs/") {
+		defs = "$defs"
+	} else if strings.HasPrefix(ref, "#/definitions/") {
+		defs = "definitions"
+	} else {
+		return nil, &JSONSchemaError{Path: path + "/$ref", Err: "unsupported reference " + ref}
+	}
+	all, _ := p.root[defs].(map[string]interface{})
+	node, ok := all[p.modelName(ref)].(map[string]interface{})
+	if !ok {
+		return nil, &JSONSchemaError{Path: path + "/$ref", Err: "reference " + ref + " not found"}
+	}
+	return p.parseModel(ref, node, ref)
+}
+
+func (p *jsonSchemaParser) parse(node map[string]interface{}, path string) (s *Schema, err error) {
+	node, err = mergeAllOf(node, path)
+	if err != nil {
+		return nil, err
+	}
+	s = &Schema{}
+	if ref, ok := node["$ref"]; ok {
+		str, ok := ref.(string)
+		if !ok {
+			return nil, &JSONSchemaError{Path: path + "/$ref", Err: "need string"}
+		}
+		s, err = p.resolve(str, path)
+		if err != nil {
+			return nil, err
+		}
+		// the keywords next to the reference and the "required" of the parent only change the copy, the
+		// same as the properties of the Go structs, see propertyOf
+		s = s.propertyOf()
+		s.Properties = append(Properties(nil), s.Properties...)
+	}
+	if tv, ok := node["type"]; ok {
+		var integer bool
+		s.Type, integer, err = parseJSONSchemaType(tv)
+		if err != nil {
+			return nil, &JSONSchemaError{Path: path + "/type", Err: err.Error()}
+		}
+		if integer {
+			s.WithInteger(true)
+		}
+	} else if s.Ref == "" && s.Type == "" {
+		if _, ok := node["properties"]; ok {
+			s.Type = Object
+		} else if _, ok := node["additionalProperties"]; ok {
+			s.Type = Object
+		} else if _, ok := node["items"]; ok {
+			s.Type = Array
+		}
+	}
+	if s.Type == String {
+		if _, ok := node["contentMediaType"]; ok {
+			s.Type = File
+		} else if _, ok := node["contentEncoding"]; ok {
+			s.Type = File
+		}
+	}
+	keys := make([]string, 0, len(node))
+	for key := range node {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	// the conditional keywords refer to the properties
+	sort.SliceStable(keys, func(i, j int) bool {
+		return !isConditionalKeyword(keys[i]) && isConditionalKeyword(keys[j])
+	})
+	for _, key := range keys {
+		err = p.parseKeyword(s, key, node[key], path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// mergeAllOf merges the "allOf" schemas which are not the conditions into node, such as a "$ref" and the
+// rules of the property next to it, see Schema.JSONSchema
+func mergeAllOf(node map[string]interface{}, path string) (map[string]interface{}, error) {
+	all, ok := node["allOf"].([]interface{})
+	if !ok {
+		return node, nil
+	}
+	merged := make(map[string]interface{}, len(node))
+	var conditions []interface{}
+	for idx, item := range all {
+		sub, ok := item.(map[string]interface{})
+		if !ok || sub["if"] != nil {
+			conditions = append(conditions, item)
+			continue
+		}
+		for key, value := range sub {
+			if _, ok := merged[key]; ok {
+				return nil, &JSONSchemaError{Path: path + "/allOf/" + strconv.Itoa(idx) + "/" + key, Err: "duplicate keyword"}
+			}
+			merged[key] = value
+		}
+	}
+	for key, value := range node {
+		if _, ok := merged[key]; ok {
+			return nil, &JSONSchemaError{Path: path + "/" + key, Err: "duplicate keyword of allOf"}
+		}
+		merged[key] = value
+	}
+	if conditions != nil {
+		merged["allOf"] = conditions
+	} else {
+		delete(merged, "allOf")
+	}
+	return merged, nil
+}
+
+func isConditionalKeyword(key string) bool {
+	return key == "dependentRequired" || key == "allOf"
+}
+
+func (p *jsonSchemaParser) parseKeyword(s *Schema, key string, value interface{}, path string) (err error) {
+	kPath := path + "/" + key
+	if _, ok := jsonSchemaAnnotations[key]; ok {
+		return nil
+	}
+	var i int
+	var f64 float64
+	switch key {
+	case "$ref", "type", "contentMediaType", "contentEncoding":
+	case "$defs", "definitions":
+		if path != "#" {
+			return &JSONSchemaError{Path: kPath, Err: "only supported by the root schema"}
+		}
+	case "description":
+		s.Description, err = jsonString(value)
+	case "properties":
+		properties, ok := value.(map[string]interface{})
+		if !ok {
+			return &JSONSchemaError{Path: kPath, Err: "need object"}
+		}
+		names := make([]string, 0, len(properties))
+		for name := range properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			node, ok := properties[name].(map[string]interface{})
+			if !ok {
+				return &JSONSchemaError{Path: kPath + "/" + name, Err: "need object"}
+			}
+			var property *Schema
+			property, err = p.parse(node, kPath+"/"+name)
+			if err != nil {
+				return err
+			}
+			property.Name = name
+			s.Properties = append(s.Properties, property)
+		}
+	case "required":
+		required, ok := value.([]interface{})
+		if !ok {
+			return &JSONSchemaError{Path: kPath, Err: "need array"}
+		}
+		for _, r := range required {
+			name, ok := r.(string)
+			if !ok {
+				return &JSONSchemaError{Path: kPath, Err: "need array of string"}
+			}
+			s.property(name).WithRequired(true)
+		}
+	case "dependentRequired":
+		dependent, ok := value.(map[string]interface{})
+		if !ok {
+			return &JSONSchemaError{Path: kPath, Err: "need object"}
+		}
+		names := make([]string, 0, len(dependent))
+		for name := range dependent {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			var required []string
+			required, err = jsonStrings(dependent[name])
+			if err != nil {
+				return &JSONSchemaError{Path: kPath + "/" + name, Err: err.Error()}
+			}
+			for _, r := range required {
+				s.property(r).WithRequiredWith(name)
+			}
+		}
+	case "allOf":
+		conditions, ok := value.([]interface{})
+		if !ok {
+			return &JSONSchemaError{Path: kPath, Err: "need array"}
+		}
+		for idx, c := range conditions {
+			err = parseJSONCondition(s, c)
+			if err != nil {
+				return &JSONSchemaError{Path: kPath + "/" + strconv.Itoa(idx), Err: err.Error()}
+			}
+		}
+	case "additionalProperties":
+		if b, ok := value.(bool); ok {
+			if b {
+				// the values of any type
+				s.AdditionalProperties = &Schema{}
+			} else {
+				s.WithStrict(true)
+			}
+			return nil
+		}
+		node, ok := value.(map[string]interface{})
+		if !ok {
+			return &JSONSchemaError{Path: kPath, Err: "need object or boolean"}
+		}
+		s.AdditionalProperties, err = p.parse(node, kPath)
+		if err != nil {
+			return err
+		}
+	case "minProperties":
+		if i, err = jsonInt(value); err == nil {
+			s.WithMinProps(i)
+		}
+	case "maxProperties":
+		if i, err = jsonInt(value); err == nil {
+			s.WithMaxProps(i)
+		}
+	case "propertyNames":
+		err = parsePropertyNames(s, value)
+	case "items":
+		node, ok := value.(map[string]interface{})
+		if !ok {
+			return &JSONSchemaError{Path: kPath, Err: "need object"}
+		}
+		s.Items, err = p.parse(node, kPath)
+		if err != nil {
+			return err
+		}
+	case "minLength", "minItems":
+		if i, err = jsonInt(value); err == nil {
+			if key == "minLength" {
+				s.WithMinLen(i)
+			} else {
+				s.WithMinItems(i)
+			}
+		}
+	case "maxLength", "maxItems":
+		if i, err = jsonInt(value); err == nil {
+			if key == "maxLength" {
+				s.WithMaxLen(i)
+			} else {
+				s.WithMaxItems(i)
+			}
+		}
+	case "minimum":
+		if f64, err = jsonNumber(value); err == nil {
+			s.WithMinNum(f64)
+		}
+	case "maximum":
+		if f64, err = jsonNumber(value); err == nil {
+			s.WithMaxNum(f64)
+		}
+	case "exclusiveMinimum":
+		if f64, err = jsonNumber(value); err == nil {
+			s.WithMinExcNum(f64)
+		}
+	case "exclusiveMaximum":
+		if f64, err = jsonNumber(value); err == nil {
+			s.WithMaxExcNum(f64)
+		}
+	case "pattern":
+		var pattern string
+		if pattern, err = jsonString(value); err == nil {
+			err = s.withPattern(pattern)
+		}
+	case "const":
+		b, ok := value.(bool)
+		if !ok {
+			return &JSONSchemaError{Path: kPath, Err: "only boolean is supported"}
+		}
+		s.WithConst(b)
+	case "format":
+		var format string
+		if format, err = jsonString(value); err == nil {
+			err = s.withFormat(format)
+		}
+	case "enum":
+		values, ok := value.([]interface{})
+		if !ok {
+			return &JSONSchemaError{Path: kPath, Err: "need array"}
+		}
+		enum := make([]string, len(values))
+		for idx, v := range values {
+			enum[idx], err = jsonScalar(v)
+			if err != nil {
+				return &JSONSchemaError{Path: kPath, Err: err.Error()}
+			}
+		}
+		err = s.withEnum(enum)
+	default:
+		return &JSONSchemaError{Path: kPath, Err: "unsupported keyword"}
+	}
+	if err != nil {
+		return &JSONSchemaError{Path: kPath, Err: err.Error()}
+	}
+	return nil
+}
+
+// property returns the property of the name, it is added if not found
+func (s *Schema) property(name string) *Schema {
+	property := s.Property(name)
+	if property == nil {
+		property = &Schema{Name: name}
+		s.Properties = append(s.Properties, property)
+	}
+	return property
+}
+
+// parsePropertyNames parses the "propertyNames" schema of the keys, only the "pattern", "minLength" and
+// "maxLength" keywords are supported
+func parsePropertyNames(s *Schema, v interface{}) error {
+	node, ok := v.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("need object")
+	}
+	for key, value := range node {
+		var err error
+		switch key {
+		case "type":
+			if kind, _ := value.(string); kind != "string" {
+				err = fmt.Errorf("the keys must be strings")
+			}
+		case "pattern":
+			var pattern string
+			if pattern, err = jsonString(value); err == nil {
+				err = s.withKeyPattern(pattern)
+			}
+		case "minLength", "maxLength":
+			var i int
+			if i, err = jsonInt(value); err == nil {
+				if key == "minLength" {
+					s.WithKeyMinLen(i)
+				} else {
+					s.WithKeyMaxLen(i)
+				}
+			}
+		default:
+			err = fmt.Errorf("unsupported keyword %s", key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseJSONCondition parses a condition exported by jsonSchemaExporter.exportConditions, which is
+// {"if": {"properties": {field: {"enum": values}}, "required": [field]}, "then": {"required": names}}
+// or {"if": {"not": {"required": fields}}, "then": {"required": names}}
+func parseJSONCondition(s *Schema, v interface{}) error {
+	node, ok := v.(map[string]interface{})
+	if !ok || len(node) != 2 {
+		return fmt.Errorf("only the conditions of the required properties are supported")
+	}
+	ifNode, ok := node["if"].(map[string]interface{})
+	thenNode, ok2 := node["then"].(map[string]interface{})
+	if !ok || !ok2 || len(thenNode) != 1 {
+		return fmt.Errorf("need the \"if\" and \"then\" schemas of the required properties")
+	}
+	names, err := jsonStrings(thenNode["required"])
+	if err != nil {
+		return fmt.Errorf("then/required: %v", err)
+	}
+	if not, ok := ifNode["not"].(map[string]interface{}); ok && len(ifNode) == 1 && len(not) == 1 {
+		fields, err := jsonStrings(not["required"])
+		if err != nil {
+			return fmt.Errorf("if/not/required: %v", err)
+		}
+		for _, name := range names {
+			s.property(name).WithRequiredWithout(fields...)
+		}
+		return nil
+	}
+	properties, ok := ifNode["properties"].(map[string]interface{})
+	if !ok || len(properties) != 1 {
+		return fmt.Errorf("need the \"if\" schema of one property")
+	}
+	for field, fv := range properties {
+		fNode, ok := fv.(map[string]interface{})
+		if !ok || len(fNode) != 1 {
+			return fmt.Errorf("need the \"enum\" or \"const\" of %s", field)
+		}
+		var values []interface{}
+		if enum, ok := fNode["enum"].([]interface{}); ok {
+			values = enum
+		} else if c, ok := fNode["const"]; ok {
+			values = []interface{}{c}
+		} else {
+			return fmt.Errorf("need the \"enum\" or \"const\" of %s", field)
+		}
+		strs := make([]string, len(values))
+		for i, value := range values {
+			strs[i], err = jsonScalar(value)
+			if err != nil {
+				return err
+			}
+		}
+		for _, name := range names {
+			s.property(name).WithRequiredIf(field, strs...)
+		}
+	}
+	return nil
+}
+
+// jsonScalar returns the string of a JSON string, number or boolean
+func jsonScalar(v interface{}) (string, error) {
+	switch ev := v.(type) {
+	case string:
+		return ev, nil
+	case float64:
+		return strconv.FormatFloat(ev, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(ev), nil
+	}
+	return "", fmt.Errorf("unsupported enum value %v", v)
+}
+
+func jsonStrings(v interface{}) ([]string, error) {
+	values, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("need array of string")
+	}
+	strs := make([]string, len(values))
+	for i, value := range values {
+		str, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("need array of string")
+		}
+		strs[i] = str
+	}
+	return strs, nil
+}
+
+// parseJSONSchemaType returns the kind of the type, integer reports whether the type is "integer"
+func parseJSONSchemaType(v interface{}) (kind JsonKind, integer bool, err error) {
+	var kinds []string
+	switch tv := v.(type) {
+	case string:
+		kinds = []string{tv}
+	case []interface{}:
+		for _, t := range tv {
+			str, ok := t.(string)
+			if !ok {
+				return "", false, fmt.Errorf("need string or array of string")
+			}
+			// a null value is the same as an absent value
+			if str != "null" {
+				kinds = append(kinds, str)
+			}
+		}
+	default:
+		return "", false, fmt.Errorf("need string or array of string")
+	}
+	if len(kinds) != 1 {
+		return "", false, fmt.Errorf("unsupported multiple types %v", kinds)
+	}
+	kind, ok := jsonSchemaKinds[kinds[0]]
+	if !ok {
+		return "", false, fmt.Errorf("unsupported type %s", kinds[0])
+	}
+	return kind, kinds[0] == "integer", nil
+}
+
+func jsonString(v interface{}) (string, error) {
+	str, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("need string, got %v", v)
+	}
+	return str, nil
+}
+
+func jsonNumber(v interface{}) (float64, error) {
+	f64, ok := v.(float64)
+	if !ok {
+		return 0, fmt.Errorf("need number, got %v", v)
+	}
+	return f64, nil
+}
+
+func jsonInt(v interface{}) (int, error) {
+	f64, err := jsonNumber(v)
+	if err != nil {
+		return 0, err
+	}
+	if f64 != math.Trunc(f64) || f64 < 0 {
+		return 0, fmt.Errorf("need non-negative integer, got %v", v)
+	}
+	return int(f64), nil
+}
@@ -0,0 +1,410 @@
+// Copyright 2020 orivil.com. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found at https://mit-license.org.
+
+package schema_test
+
+import (
+	"encoding/json"
+	"github.com/orivil/schema"
+	"strings"
+	"testing"
+)
+
+type Node struct {
+	Name     string          `json:"name" schema:"required;minLen:2;pattern:^\\w+$" desc:"node name"`
+	Weight   float64         `json:"weight" schema:"minExcNum:0;maxNum:1"`
+	Kind     int             `json:"kind" schema:"enum:1,2"`
+	Tags     []string        `json:"tags" schema:"maxItems:3"`
+	Children []*Node         `json:"children"`
+	Avatar   schema.FileData `json:"avatar"`
+}
+
+func TestJSONSchema(t *testing.T) {
+	s, err := schema.NewSchema(&Node{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := jsonStr(s.JSONSchema())
+	need :=
+		`{
+	"$defs": {
+		"github.com/orivil/schema_test.Node": {
+			"properties": {
+				"avatar": {
+					"contentMediaType": "application/octet-stream",
+					"type": "string"
+				},
+				"children": {
+					"items": {
+						"$ref": "#/$defs/github.com~1orivil~1schema_test.Node"
+					},
+					"type": "array"
+				},
+				"kind": {
+					"enum": [
+						1,
+						2
+					],
+					"type": "number"
+				},
+				"name": {
+					"description": "node name",
+					"minLength": 2,
+					"pattern": "^\\w+$",
+					"type": "string"
+				},
+				"tags": {
+					"items": {
+						"type": "string"
+					},
+					"maxItems": 3,
+					"type": "array"
+				},
+				"weight": {
+					"exclusiveMinimum": 0,
+					"maximum": 1,
+					"type": "number"
+				}
+			},
+			"required": [
+				"name"
+			],
+			"type": "object"
+		}
+	},
+	"$ref": "#/$defs/github.com~1orivil~1schema_test.Node",
+	"$schema": "https://json-schema.org/draft/2020-12/schema"
+}`
+	if got != need {
+		t.Fatalf("need: %s\ngot: %s", need, got)
+	}
+}
+
+func TestParseJSONSchema(t *testing.T) {
+	doc := `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"title": "order",
+	"type": "object",
+	"required": ["id", "items"],
+	"properties": {
+		"id": {"type": "string", "pattern": "^[0-9]+$", "maxLength": 8},
+		"status": {"type": "integer", "enum": [1, 2]},
+		"discount": {"type": "number", "exclusiveMaximum": 10},
+		"quantity": {"type": "integer"},
+		"paid": {"type": "boolean"},
+		"items": {"type": "array", "minItems": 1, "items": {"$ref": "#/$defs/item"}}
+	},
+	"$defs": {
+		"item": {
+			"type": "object",
+			"required": ["count"],
+			"properties": {
+				"count": {"type": "number", "exclusiveMinimum": 0},
+				"file": {"type": "string", "contentMediaType": "image/png"}
+			}
+		}
+	}
+}`
+	s, err := schema.ParseJSONSchema([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Model != "order" || s.Property("items").Items.Model != "item" || s.Property("items").Items.Property("file").Type != schema.File {
+		t.Fatalf("got: %s", jsonStr(s))
+	}
+	type testCase struct {
+		payload string
+		field   string
+		rule    string
+	}
+	testCases := []testCase{
+		{`{"id": "12", "status": 1, "items": [{"count": 1}]}`, "", ""},
+		{`{"status": 1, "items": [{"count": 1}]}`, "id", schema.OptionsRequired},
+		{`{"id": "1a", "items": [{"count": 1}]}`, "id", schema.Pattern},
+		{`{"id": "12", "status": 3, "items": [{"count": 1}]}`, "status", schema.Enum},
+		{`{"id": "12", "items": []}`, "items", schema.MinItems},
+		{`{"id": "12", "discount": 9.5, "items": [{"count": 1}]}`, "", ""},
+		{`{"id": "12", "discount": 10, "items": [{"count": 1}]}`, "discount", schema.MaxExcNum},
+		{`{"id": "12", "quantity": 2, "paid": true, "items": [{"count": 1}]}`, "", ""},
+		{`{"id": "12", "quantity": 1.5, "items": [{"count": 1}]}`, "quantity", schema.Integer},
+		{`{"id": "12", "quantity": "2", "items": [{"count": 1}]}`, "quantity", schema.RuleType},
+		{`{"id": 12, "items": [{"count": 1}]}`, "id", schema.RuleType},
+		{`{"id": "12", "paid": "yes", "items": [{"count": 1}]}`, "paid", schema.RuleType},
+		{`{"id": "12", "items": [{"count": 1}, {"count": 0}]}`, "items[1].count", schema.MinExcNum},
+	}
+	for _, tc := range testCases {
+		var payload map[string]interface{}
+		err = json.Unmarshal([]byte(tc.payload), &payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		info, err := s.Valid(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tc.rule == "" {
+			if info != nil {
+				t.Errorf("payload %s need no failure, got: %s", tc.payload, jsonStr(info))
+			}
+		} else if info == nil || info.Field != tc.field || info.Rule() != tc.rule {
+			t.Errorf("payload %s need %s %s, got: %s", tc.payload, tc.field, tc.rule, jsonStr(info))
+		}
+	}
+}
+
+func TestParseJSONSchemaErrors(t *testing.T) {
+	docs := map[string]string{
+		`{"type": "object", "properties": {"a": {"type": "string", "oneOf": []}}}`: "#/properties/a/oneOf",
+		`{"type": "array", "items": {"$ref": "http://example.com/a.json"}}`:        "#/items/$ref",
+		`{"type": "object", "properties": {"a": {"$ref": "#/$defs/b"}}}`:           "#/properties/a/$ref",
+		`{"type": ["string", "number"]}`:                                           "#/type",
+		`{"type": "string", "minLength": 1.5}`:                                     "#/minLength",
+	}
+	for doc, path := range docs {
+		_, err := schema.ParseJSONSchema([]byte(doc))
+		e, ok := err.(*schema.JSONSchemaError)
+		if !ok || e.Path != path {
+			t.Errorf("document %s need error at %s, got: %v", doc, path, err)
+		}
+	}
+}
+
+func TestParseExportedJSONSchema(t *testing.T) {
+	s, err := schema.NewSchema(&Node{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := s.MarshalJSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := schema.ParseJSONSchema(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := jsonStr(parsed.JSONSchema())
+	need := jsonStr(s.JSONSchema())
+	if got != need {
+		t.Fatalf("need: %s\ngot: %s", need, got)
+	}
+}
+
+func TestParseRecursiveJSONSchema(t *testing.T) {
+	// the required head does not make the recursive references required
+	s, err := schema.ParseJSONSchema([]byte(`{
+	"$defs": {
+		"item": {
+			"type": "object",
+			"properties": {"v": {"type": "string"}, "next": {"$ref": "#/$defs/item"}},
+			"required": ["v"]
+		}
+	},
+	"type": "object",
+	"properties": {"head": {"$ref": "#/$defs/item", "description": "first item"}},
+	"required": ["head"]
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vs := s.Property("head").Validations; vs == nil || !vs.Required {
+		t.Fatalf("need required head, got: %s", jsonStr(s.Property("head")))
+	}
+	cases := []struct {
+		input string
+		need  []string
+	}{
+		{`{"head": {"v": "a"}}`, nil},
+		{`{"head": {"v": "a", "next": {"v": "b"}}}`, nil},
+		{`{"head": {"v": "a", "next": {}}}`, []string{"head.next.v"}},
+		{`{}`, []string{"head"}},
+	}
+	for i, c := range cases {
+		var v interface{}
+		if err = json.Unmarshal([]byte(c.input), &v); err != nil {
+			t.Fatal(err)
+		}
+		infos, err := s.ValidAll(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, info := range infos {
+			got = append(got, info.Field)
+		}
+		if len(got) != len(c.need) || len(got) > 0 && got[0] != c.need[0] {
+			t.Errorf("case %d need failures of %v, got: %s", i, c.need, jsonStr(infos))
+		}
+	}
+	if got := jsonStr(s.JSONSchema()); strings.Count(got, "first item") != 1 {
+		t.Errorf("need the description of the head only, got: %s", got)
+	}
+}
+
+func TestRefRulesJSONSchema(t *testing.T) {
+	// the rules of the properties are exported next to the references, the model keeps its own rules
+	type team struct {
+		Name string `json:"name"`
+	}
+	type project struct {
+		Owner  *team `json:"owner" schema:"strict"`
+		Backup *team `json:"backup" schema:"minProps:1"`
+		Extra  *team `json:"extra"`
+	}
+	s, err := schema.NewSchema(&project{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := s.JSONSchema()
+	defs := doc["$defs"].(map[string]interface{})
+	ref := `{"$ref":"#/$defs/github.com~1orivil~1schema_test.team"}`
+	got := jsonCompact(defs["github.com/orivil/schema_test.project"])
+	need := `{"properties":{` +
+		`"backup":{"allOf":[` + ref + `,{"minProperties":1}]},` +
+		`"extra":` + ref + `,` +
+		`"owner":{"allOf":[` + ref + `,{"additionalProperties":false}]}},"type":"object"}`
+	if got != need {
+		t.Fatalf("need: %s\ngot: %s", need, got)
+	}
+	need = `{"properties":{"name":{"type":"string"}},"type":"object"}`
+	if got = jsonCompact(defs["github.com/orivil/schema_test.team"]); got != need {
+		t.Fatalf("need: %s\ngot: %s", need, got)
+	}
+	data, err := s.MarshalJSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := schema.ParseJSONSchema(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, need := jsonStr(parsed.JSONSchema()), jsonStr(doc); got != need {
+		t.Fatalf("need: %s\ngot: %s", need, got)
+	}
+	var payload map[string]interface{}
+	_ = json.Unmarshal([]byte(`{"owner": {"name": "a", "age": 1}, "backup": {}, "extra": {}}`), &payload)
+	infos, err := parsed.ValidAll(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 2 || infos[0].Field != "backup" || infos[1].Field != "owner.age" {
+		t.Fatalf("need failures of backup and owner.age, got: %s", jsonStr(infos))
+	}
+}
+
+func TestConditionalJSONSchema(t *testing.T) {
+	type account struct {
+		Type     int    `json:"type"`
+		Company  string `json:"company" schema:"requiredIf:type=2"`
+		Email    string `json:"email" schema:"requiredWithout:mobile"`
+		Mobile   string `json:"mobile"`
+		Password string `json:"password"`
+		Confirm  string `json:"confirm" schema:"requiredWith:password"`
+	}
+	s, err := schema.NewSchema(&account{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := s.JSONSchema()
+	model := doc["$defs"].(map[string]interface{})["github.com/orivil/schema_test.account"]
+	got := jsonCompact(model.(map[string]interface{})["allOf"])
+	need := `[{"if":{"properties":{"type":{"enum":[2]}},"required":["type"]},"then":{"required":["company"]}},` +
+		`{"if":{"not":{"required":["mobile"]}},"then":{"required":["email"]}}]`
+	if got != need {
+		t.Fatalf("need: %s\ngot: %s", need, got)
+	}
+	if got := jsonCompact(model.(map[string]interface{})["dependentRequired"]); got != `{"password":["confirm"]}` {
+		t.Fatalf("got dependentRequired: %s", got)
+	}
+	data, err := s.MarshalJSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := schema.ParseJSONSchema(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, need := jsonStr(parsed.JSONSchema()), jsonStr(doc); got != need {
+		t.Fatalf("need: %s\ngot: %s", need, got)
+	}
+	var payload map[string]interface{}
+	_ = json.Unmarshal([]byte(`{"type": 2, "email": "a@b.c"}`), &payload)
+	info, err := parsed.Valid(payload)
+	if err != nil || info == nil || info.Field != "company" || info.Rule() != schema.RequiredIf {
+		t.Fatalf("need requiredIf failure of company, got: %s, %v", jsonStr(info), err)
+	}
+	_, err = schema.ParseJSONSchema([]byte(`{"type": "object", "allOf": [{"if": {}, "then": {"required": ["a"]}}]}`))
+	if e, ok := err.(*schema.JSONSchemaError); !ok || e.Path != "#/allOf/0" {
+		t.Errorf("need error at #/allOf/0, got: %v", err)
+	}
+}
+
+func jsonCompact(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
+
+func TestMapJSONSchema(t *testing.T) {
+	type shop struct {
+		Prices map[string]float64 `json:"prices" schema:"maxProps:2;keyPattern:^\\w+$;keyMaxLen:8" schemaValue:"minNum:0"`
+	}
+	s, err := schema.NewSchema(&shop{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := s.JSONSchema()
+	model := doc["$defs"].(map[string]interface{})["github.com/orivil/schema_test.shop"]
+	got := jsonCompact(model.(map[string]interface{})["properties"])
+	need := `{"prices":{"additionalProperties":{"minimum":0,"type":"number"},"maxProperties":2,` +
+		`"propertyNames":{"maxLength":8,"pattern":"^\\w+$"},"type":"object"}}`
+	if got != need {
+		t.Fatalf("need: %s\ngot: %s", need, got)
+	}
+	data, err := s.MarshalJSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := schema.ParseJSONSchema(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, need := jsonStr(parsed.JSONSchema()), jsonStr(doc); got != need {
+		t.Fatalf("need: %s\ngot: %s", need, got)
+	}
+	var payload map[string]interface{}
+	_ = json.Unmarshal([]byte(`{"prices": {"apple": -1}}`), &payload)
+	info, err := parsed.Valid(payload)
+	if err != nil || info == nil || info.Field != "prices.apple" || info.Rule() != schema.MinNum {
+		t.Fatalf("need minNum failure of prices.apple, got: %s, %v", jsonStr(info), err)
+	}
+	_, err = schema.ParseJSONSchema([]byte(`{"type": "object", "propertyNames": {"format": "email"}}`))
+	if e, ok := err.(*schema.JSONSchemaError); !ok || e.Path != "#/propertyNames" {
+		t.Errorf("need error at #/propertyNames, got: %v", err)
+	}
+}
+
+func TestStrictJSONSchema(t *testing.T) {
+	s, err := schema.ParseJSONSchema([]byte(`{
+	"type": "object",
+	"additionalProperties": false,
+	"properties": {"name": {"type": "string"}, "meta": {"type": "object", "additionalProperties": true}}
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := jsonCompact(s.JSONSchema()["$defs"].(map[string]interface{})["root"])
+	need := `{"additionalProperties":false,"properties":{"meta":{"additionalProperties":{},"type":"object"},` +
+		`"name":{"type":"string"}},"type":"object"}`
+	if got != need {
+		t.Fatalf("need: %s\ngot: %s", need, got)
+	}
+	var payload map[string]interface{}
+	_ = json.Unmarshal([]byte(`{"name": "a", "age": 1, "meta": {"k": 1}}`), &payload)
+	info, err := s.Valid(payload, schema.Strict())
+	if err != nil || info == nil || info.Field != "age" || info.Rule() != schema.OptionsStrict {
+		t.Fatalf("need strict failure of age, got: %s, %v", jsonStr(info), err)
+	}
+}
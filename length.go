@@ -0,0 +1,77 @@
+// Copyright 2020 orivil.com. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found at https://mit-license.org.
+
+package schema
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+func stringLength(str, unit string) int {
+	switch unit {
+	case LenUnitRune:
+		return utf8.RuneCountInString(str)
+	case LenUnitGrapheme:
+		return graphemeCount(str)
+	default:
+		return len(str)
+	}
+}
+
+const (
+	zeroWidthJoiner = 0x200D
+	// the first regional indicator symbol, two symbols make a flag
+	regionalIndicatorA = 0x1F1E6
+	regionalIndicatorZ = 0x1F1FF
+)
+
+// graphemeCount counts the extended grapheme clusters of str, it covers the common rules of Unicode
+// Standard Annex #29: CR LF, combining marks, variation selectors, emoji modifiers and tags, zero
+// width joiner sequences, regional indicator pairs and Hangul jamo
+func graphemeCount(str string) int {
+	count := 0
+	var prev rune = -1
+	regionalIndicators := 0
+	for _, r := range str {
+		extend := false
+		switch {
+		case prev == -1:
+		case prev == '\r' && r == '\n':
+			extend = true
+		case r == zeroWidthJoiner || prev == zeroWidthJoiner:
+			extend = true
+		case unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc):
+			extend = true
+		case r >= 0xFE00 && r <= 0xFE0F, r >= 0xE0100 && r <= 0xE01EF:
+			// variation selectors
+			extend = true
+		case r >= 0x1F3FB && r <= 0x1F3FF:
+			// emoji modifiers
+			extend = true
+		case r >= 0xE0020 && r <= 0xE007F:
+			// tags of emoji flags
+			extend = true
+		case r >= regionalIndicatorA && r <= regionalIndicatorZ:
+			extend = regionalIndicators%2 == 1
+		case isHangulJamo(prev) && r >= 0x1160 && r <= 0x11FF, isHangulJamo(prev) && r >= 0xD7B0 && r <= 0xD7FF:
+			// the vowels and trailing consonants of the Hangul jamo
+			extend = true
+		}
+		if r >= regionalIndicatorA && r <= regionalIndicatorZ {
+			regionalIndicators++
+		} else {
+			regionalIndicators = 0
+		}
+		if !extend {
+			count++
+		}
+		prev = r
+	}
+	return count
+}
+
+func isHangulJamo(r rune) bool {
+	return r >= 0x1100 && r <= 0x11FF || r >= 0xA960 && r <= 0xA97F || r >= 0xD7B0 && r <= 0xD7FF || r >= 0xAC00 && r <= 0xD7A3
+}
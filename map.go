@@ -0,0 +1,199 @@
+// Copyright 2020 orivil.com. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found at https://mit-license.org.
+
+package schema
+
+import (
+	"github.com/orivil/types"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ValueTag is the tag of the rules of the map values, such as `schemaValue:"required;maxLen:10"`, the
+// rules of the map itself are in the "schema" tag, such as `schema:"maxProps:10;keyPattern:^\\w+$"`
+const ValueTag = "schemaValue"
+
+func (vs *Validations) isPropertiesValidations() bool {
+	return vs.MinProps != nil || vs.MaxProps != nil || vs.KeyPattern != "" || vs.KeyMinLen != nil || vs.KeyMaxLen != nil
+}
+
+// validProperties checks the number and the keys of the map v, actual is the failed key or v
+func (vs *Validations) validProperties(v interface{}, lenUnit string) (info *Validations, actual interface{}, err error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map || !vs.isPropertiesValidations() {
+		return nil, nil, nil
+	}
+	count := rv.Len()
+	if vs.MinProps != nil && *vs.MinProps > count {
+		return &Validations{MinProps: vs.MinProps}, v, nil
+	}
+	if vs.MaxProps != nil && *vs.MaxProps < count {
+		return &Validations{MaxProps: vs.MaxProps}, v, nil
+	}
+	if vs.KeyPattern == "" && vs.KeyMinLen == nil && vs.KeyMaxLen == nil {
+		return nil, nil, nil
+	}
+	keys, err := mapKeys(rv)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, key := range keys {
+		if vs.KeyPattern != "" {
+			matcher, err := patterns.Compile(vs.KeyPattern)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !matcher.MatchString(key.name) {
+				return &Validations{KeyPattern: vs.KeyPattern}, key.name, nil
+			}
+		}
+		length := stringLength(key.name, lenUnit)
+		if vs.KeyMinLen != nil && *vs.KeyMinLen > length {
+			return &Validations{KeyMinLen: vs.KeyMinLen}, key.name, nil
+		}
+		if vs.KeyMaxLen != nil && *vs.KeyMaxLen < length {
+			return &Validations{KeyMaxLen: vs.KeyMaxLen}, key.name, nil
+		}
+	}
+	return nil, nil, nil
+}
+
+type mapKey struct {
+	name  string
+	value reflect.Value
+}
+
+// mapKeys returns the keys of the map v sorted by the names
+func mapKeys(v reflect.Value) ([]mapKey, error) {
+	keys := make([]mapKey, 0, v.Len())
+	for _, key := range v.MapKeys() {
+		name := ""
+		if key.Kind() == reflect.String {
+			name = key.String()
+		} else {
+			pv, err := types.GetValue(indirectInterface(key).Interface())
+			if err != nil {
+				return nil, err
+			}
+			name = pv.String()
+		}
+		keys = append(keys, mapKey{name: name, value: key})
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].name < keys[j].name
+	})
+	return keys, nil
+}
+
+// validAdditional validates the values of the map v whose keys are not the properties
+func (s *Schema) validAdditional(vr *validator, field string, v reflect.Value) error {
+	keys, err := mapKeys(v)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if s.Property(key.name) != nil {
+			continue
+		}
+		err = s.AdditionalProperties.valid(vr, initFieldName(field, key.name), v.MapIndex(key.value))
+		if err != nil || vr.stopped() {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Schema) withMapTagOptions(opts tagOptions) error {
+	for _, name := range []string{MinProps, MaxProps, KeyMinLen, KeyMaxLen} {
+		if str := opts.GetValue(name); str != "" {
+			i, err := types.String(str).Int()
+			if err != nil {
+				return &TagError{Tag: Tag + "." + name, Err: err.Error()}
+			}
+			switch name {
+			case MinProps:
+				s.WithMinProps(i)
+			case MaxProps:
+				s.WithMaxProps(i)
+			case KeyMinLen:
+				s.WithKeyMinLen(i)
+			case KeyMaxLen:
+				s.WithKeyMaxLen(i)
+			}
+		}
+	}
+	if pattern := opts.GetValue(KeyPattern); pattern != "" {
+		err := s.withKeyPattern(pattern)
+		if err != nil {
+			return &TagError{Tag: Tag + "." + KeyPattern, Err: err.Error()}
+		}
+	}
+	return nil
+}
+
+// withValueTagOptions sets the rules of the map values by the options of the ValueTag
+func (s *Schema) withValueTagOptions(optStr string) error {
+	if s.AdditionalProperties == nil {
+		return &TagError{Tag: ValueTag, Err: "only supported by maps"}
+	}
+	err := s.AdditionalProperties.withTagOptions(optStr)
+	if e, ok := err.(*TagError); ok {
+		e.Tag = ValueTag + strings.TrimPrefix(e.Tag, Tag)
+	}
+	return err
+}
+
+// WithAdditionalProperties sets the schema of the map values whose keys are not the properties
+func (s *Schema) WithAdditionalProperties(values *Schema) *Schema {
+	s.AdditionalProperties = values
+	return s
+}
+
+// WithMinProps sets the min number of the map entries
+func (s *Schema) WithMinProps(minProps int) *Schema {
+	s.initValidation()
+	s.Validations.MinProps = &minProps
+	return s
+}
+
+// WithMaxProps sets the max number of the map entries
+func (s *Schema) WithMaxProps(maxProps int) *Schema {
+	s.initValidation()
+	s.Validations.MaxProps = &maxProps
+	return s
+}
+
+// WithKeyPattern sets the pattern of the map keys
+func (s *Schema) WithKeyPattern(pattern string) *Schema {
+	err := s.withKeyPattern(pattern)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func (s *Schema) withKeyPattern(pattern string) error {
+	_, err := patterns.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	s.initValidation()
+	s.Validations.KeyPattern = pattern
+	return nil
+}
+
+// WithKeyMinLen sets the min length of the map keys, the length is counted by the LenUnit
+func (s *Schema) WithKeyMinLen(minLen int) *Schema {
+	s.initValidation()
+	s.Validations.KeyMinLen = &minLen
+	return s
+}
+
+// WithKeyMaxLen sets the max length of the map keys, the length is counted by the LenUnit
+func (s *Schema) WithKeyMaxLen(maxLen int) *Schema {
+	s.initValidation()
+	s.Validations.KeyMaxLen = &maxLen
+	return s
+}
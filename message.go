@@ -0,0 +1,247 @@
+// Copyright 2020 orivil.com. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found at https://mit-license.org.
+
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+const (
+	LocaleEnUS = "en-US"
+	LocaleZhCN = "zh-CN"
+)
+
+// MessageData is the data for rendering the message templates
+type MessageData struct {
+	// Name is the description of the field, or the field path if the description is empty
+	Name        string
+	Field       string
+	Description string
+	Rule        string
+	Expected    interface{}
+	Actual      interface{}
+}
+
+var messageFuncs = template.FuncMap{
+	"join": func(v interface{}, sep string) string {
+		if strs, ok := v.([]string); ok {
+			return strings.Join(strs, sep)
+		}
+		return fmt.Sprint(v)
+	},
+}
+
+func parseMessage(text string) (*template.Template, error) {
+	return template.New("").Funcs(messageFuncs).Parse(text)
+}
+
+// Catalog holds the message templates of the rules for every locale
+type Catalog struct {
+	// Fallback is the locale used if a template is not found
+	Fallback  string
+	mu        sync.RWMutex
+	templates map[string]map[string]*template.Template
+}
+
+func NewCatalog(fallback string) *Catalog {
+	return &Catalog{Fallback: fallback, templates: make(map[string]map[string]*template.Template)}
+}
+
+// DefaultCatalog holds the bundled en-US and zh-CN templates, they could be overridden by Catalog.Set
+var DefaultCatalog = NewCatalog(LocaleEnUS).
+	MustSetAll(LocaleEnUS, map[string]string{
+		RuleType:        "{{.Name}} must be of type {{.Expected}}",
+		RuleValidSchema: "{{.Expected}}",
+		RuleImage:       "{{.Name}} must be a GIF, JPEG or PNG image",
+		OptionsRequired: "{{.Name}} is required",
+		OptionsStrict:   "{{.Name}} is not allowed",
+		RequiredIf:      `{{.Name}} is required when {{.Expected.Field}} is {{join .Expected.Values " or "}}`,
+		RequiredWith:    `{{.Name}} is required when {{join .Expected " or "}} is present`,
+		RequiredWithout: `{{.Name}} is required when {{join .Expected " or "}} is absent`,
+		Enum:            `{{.Name}} must be one of {{join .Expected ", "}}`,
+		Pattern:         "{{.Name}} does not match the pattern {{.Expected}}",
+		Format:          "{{.Name}} must be a valid {{.Expected}}",
+		MinLen:          "{{.Name}} must be at least {{.Expected}} characters long",
+		MaxLen:          "{{.Name}} must be at most {{.Expected}} characters long",
+		MinItems:        "{{.Name}} must contain at least {{.Expected}} items",
+		MaxItems:        "{{.Name}} must contain at most {{.Expected}} items",
+		MinNum:          "{{.Name}} must be greater than or equal to {{.Expected}}",
+		MaxNum:          "{{.Name}} must be less than or equal to {{.Expected}}",
+		MinExcNum:       "{{.Name}} must be greater than {{.Expected}}",
+		MaxExcNum:       "{{.Name}} must be less than {{.Expected}}",
+		Integer:         "{{.Name}} must be an integer",
+		Use:             "{{.Name}} is invalid",
+		Const:           "{{.Name}} must be {{.Expected}}",
+		MinSize:         "{{.Name}} must be at least {{.Expected}} bytes",
+		MaxSize:         "{{.Name}} must be at most {{.Expected}} bytes",
+		Mime:            `{{.Name}} must be a file of type {{join .Expected ", "}}`,
+		Ext:             `{{.Name}} must be a file with extension {{join .Expected ", "}}`,
+		MinWidth:        "{{.Name}} must be at least {{.Expected}} pixels wide",
+		MaxWidth:        "{{.Name}} must be at most {{.Expected}} pixels wide",
+		MinHeight:       "{{.Name}} must be at least {{.Expected}} pixels high",
+		MaxHeight:       "{{.Name}} must be at most {{.Expected}} pixels high",
+		EqField:         "{{.Name}} must be equal to {{.Expected}}",
+		NeField:         "{{.Name}} must not be equal to {{.Expected}}",
+		GtField:         "{{.Name}} must be greater than {{.Expected}}",
+		GteField:        "{{.Name}} must be greater than or equal to {{.Expected}}",
+		LtField:         "{{.Name}} must be less than {{.Expected}}",
+		LteField:        "{{.Name}} must be less than or equal to {{.Expected}}",
+		MinProps:        "{{.Name}} must contain at least {{.Expected}} entries",
+		MaxProps:        "{{.Name}} must contain at most {{.Expected}} entries",
+		KeyPattern:      "the key {{.Actual}} of {{.Name}} does not match the pattern {{.Expected}}",
+		KeyMinLen:       "the key {{.Actual}} of {{.Name}} must be at least {{.Expected}} characters long",
+		KeyMaxLen:       "the key {{.Actual}} of {{.Name}} must be at most {{.Expected}} characters long",
+	}).
+	MustSetAll(LocaleZhCN, map[string]string{
+		RuleType:        "{{.Name}}的类型必须是{{.Expected}}",
+		RuleValidSchema: "{{.Expected}}",
+		RuleImage:       "{{.Name}}必须是GIF、JPEG或PNG图片",
+		OptionsRequired: "{{.Name}}不能为空",
+		OptionsStrict:   "不允许的字段{{.Name}}",
+		RequiredIf:      `{{.Expected.Field}}为{{join .Expected.Values "或"}}时{{.Name}}不能为空`,
+		RequiredWith:    `填写{{join .Expected "或"}}时{{.Name}}不能为空`,
+		RequiredWithout: `未填写{{join .Expected "或"}}时{{.Name}}不能为空`,
+		Enum:            `{{.Name}}必须是{{join .Expected "、"}}之一`,
+		Pattern:         "{{.Name}}的格式不正确",
+		Format:          "{{.Name}}不是有效的{{.Expected}}",
+		MinLen:          "{{.Name}}的长度不能少于{{.Expected}}个字符",
+		MaxLen:          "{{.Name}}的长度不能超过{{.Expected}}个字符",
+		MinItems:        "{{.Name}}至少需要{{.Expected}}项",
+		MaxItems:        "{{.Name}}最多只能有{{.Expected}}项",
+		MinNum:          "{{.Name}}不能小于{{.Expected}}",
+		MaxNum:          "{{.Name}}不能大于{{.Expected}}",
+		MinExcNum:       "{{.Name}}必须大于{{.Expected}}",
+		MaxExcNum:       "{{.Name}}必须小于{{.Expected}}",
+		Integer:         "{{.Name}}必须是整数",
+		Use:             "{{.Name}}无效",
+		Const:           "{{.Name}}必须为{{.Expected}}",
+		MinSize:         "{{.Name}}不能小于{{.Expected}}字节",
+		MaxSize:         "{{.Name}}不能超过{{.Expected}}字节",
+		Mime:            `{{.Name}}的文件类型必须是{{join .Expected "、"}}之一`,
+		Ext:             `{{.Name}}的文件扩展名必须是{{join .Expected "、"}}之一`,
+		MinWidth:        "{{.Name}}的宽度不能小于{{.Expected}}像素",
+		MaxWidth:        "{{.Name}}的宽度不能超过{{.Expected}}像素",
+		MinHeight:       "{{.Name}}的高度不能小于{{.Expected}}像素",
+		MaxHeight:       "{{.Name}}的高度不能超过{{.Expected}}像素",
+		EqField:         "{{.Name}}必须与{{.Expected}}相同",
+		NeField:         "{{.Name}}不能与{{.Expected}}相同",
+		GtField:         "{{.Name}}必须大于{{.Expected}}",
+		GteField:        "{{.Name}}不能小于{{.Expected}}",
+		LtField:         "{{.Name}}必须小于{{.Expected}}",
+		LteField:        "{{.Name}}不能大于{{.Expected}}",
+		MinProps:        "{{.Name}}至少需要{{.Expected}}项",
+		MaxProps:        "{{.Name}}最多只能有{{.Expected}}项",
+		KeyPattern:      "{{.Name}}的键{{.Actual}}格式不正确",
+		KeyMinLen:       "{{.Name}}的键{{.Actual}}长度不能少于{{.Expected}}个字符",
+		KeyMaxLen:       "{{.Name}}的键{{.Actual}}长度不能超过{{.Expected}}个字符",
+	})
+
+// Set sets the template of the rule for the locale, the template is a text/template which is rendered
+// with the MessageData
+func (c *Catalog) Set(locale, rule, text string) error {
+	tpl, err := parseMessage(text)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.templates[locale] == nil {
+		c.templates[locale] = make(map[string]*template.Template)
+	}
+	c.templates[locale][rule] = tpl
+	return nil
+}
+
+// MustSetAll sets the templates of the rules for the locale, it panics if any template is invalid
+func (c *Catalog) MustSetAll(locale string, texts map[string]string) *Catalog {
+	for rule, text := range texts {
+		err := c.Set(locale, rule, text)
+		if err != nil {
+			panic(err)
+		}
+	}
+	return c
+}
+
+func (c *Catalog) lookup(locale, rule string) *template.Template {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if tpl := c.templates[locale][rule]; tpl != nil {
+		return tpl
+	}
+	// "zh" matches "zh-CN"
+	lang := locale
+	if idx := strings.IndexAny(locale, "-_"); idx != -1 {
+		lang = locale[:idx]
+	}
+	locales := make([]string, 0, len(c.templates))
+	for l := range c.templates {
+		locales = append(locales, l)
+	}
+	sort.Strings(locales)
+	for _, l := range locales {
+		if strings.HasPrefix(l, lang+"-") || l == lang {
+			if tpl := c.templates[l][rule]; tpl != nil {
+				return tpl
+			}
+		}
+	}
+	return c.templates[c.Fallback][rule]
+}
+
+// Message renders the message of the failure for the locale, the custom message of the field is used
+// first, and the error message is returned if no template is found, the template of a custom validator
+// is set by the name of the validator
+func (c *Catalog) Message(locale string, e *ValidationError) string {
+	tpl := c.lookup(locale, e.Rule)
+	if tpl == nil && getValidator(e.Rule) != nil {
+		// the template of a custom validator falls back to the "use" template
+		tpl = c.lookup(locale, Use)
+	}
+	if e.Message != "" {
+		custom, err := parseMessage(e.Message)
+		if err == nil {
+			tpl = custom
+		}
+	}
+	if tpl == nil {
+		return e.Error()
+	}
+	data := &MessageData{
+		Name:        e.Description,
+		Field:       e.Field,
+		Description: e.Description,
+		Rule:        e.Rule,
+		Expected:    e.Expected,
+		Actual:      e.Actual,
+	}
+	if data.Name == "" {
+		data.Name = e.Field
+	}
+	buf := &strings.Builder{}
+	err := tpl.Execute(buf, data)
+	if err != nil {
+		return e.Error()
+	}
+	return buf.String()
+}
+
+// Localize renders the message of the failure for the locale by the DefaultCatalog
+func (e *ValidationError) Localize(locale string) string {
+	return DefaultCatalog.Message(locale, e)
+}
+
+// Localize renders the messages of the failures for the locale by the DefaultCatalog
+func (es ValidationErrors) Localize(locale string) []string {
+	msgs := make([]string, len(es))
+	for i, e := range es {
+		msgs[i] = e.Localize(locale)
+	}
+	return msgs
+}
@@ -0,0 +1,65 @@
+// Copyright 2020 orivil.com. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found at https://mit-license.org.
+
+package schema_test
+
+import (
+	"errors"
+	"github.com/orivil/schema"
+	"testing"
+)
+
+func TestMessages(t *testing.T) {
+	type model struct {
+		Username string `json:"username" schema:"minLen:6" desc:"用户名"`
+		Sex      int    `json:"sex" schema:"enum:1,2"`
+		Age      int    `json:"age" schema:"minNum:18;msg:{{.Name}} must be an adult ({{.Expected}}+)"`
+	}
+	s, err := schema.NewSchema(&model{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.ValidAll(&model{Username: "Jay", Sex: 3, Age: 16}, schema.AsError())
+	var es schema.ValidationErrors
+	if !errors.As(err, &es) {
+		t.Fatalf("need ValidationErrors, got: %v", err)
+	}
+	type testCase struct {
+		locale string
+		need   []string
+	}
+	testCases := []testCase{
+		{schema.LocaleEnUS, []string{
+			"用户名 must be at least 6 characters long",
+			"sex must be one of 1, 2",
+			"age must be an adult (18+)",
+		}},
+		{"zh", []string{
+			"用户名的长度不能少于6个字符",
+			"sex必须是1、2之一",
+			"age must be an adult (18+)",
+		}},
+	}
+	for _, tc := range testCases {
+		got := es.Localize(tc.locale)
+		for i := range tc.need {
+			if got[i] != tc.need[i] {
+				t.Errorf("need: %s, got: %s", tc.need[i], got[i])
+			}
+		}
+	}
+	catalog := schema.NewCatalog("fr-FR").MustSetAll("fr-FR", map[string]string{
+		schema.MinLen: "{{.Name}} doit contenir au moins {{.Expected}} caractères",
+	})
+	if got, need := catalog.Message("de-DE", es[0]), "用户名 doit contenir au moins 6 caractères"; got != need {
+		t.Errorf("need: %s, got: %s", need, got)
+	}
+	if got, need := catalog.Message("fr-FR", es[1]), es[1].Error(); got != need {
+		t.Errorf("need: %s, got: %s", need, got)
+	}
+	err = s.Property("age").WithTagOptions(`schema:"msg:{{.Name"`)
+	if _, ok := err.(*schema.TagError); !ok {
+		t.Errorf("need *TagError, got: %v", err)
+	}
+}
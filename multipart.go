@@ -0,0 +1,84 @@
+// Copyright 2020 orivil.com. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found at https://mit-license.org.
+
+package schema
+
+import (
+	"mime/multipart"
+	"reflect"
+)
+
+// UnmarshalMultipart for un-marshaling the multipart form to v, the values are set by the same rules as
+// UnmarshalUrl, the File fields such as FileData, FormFile and their slices are set by the files. A
+// FileData reads the whole file into memory, a FormFile keeps the header only and the file is streamed
+// when it is opened, v should be pointer to struct or it's reflect value
+func UnmarshalMultipart(form *multipart.Form, v interface{}) error {
+	_, err := unmarshalMultipart(form, v, false)
+	return err
+}
+
+// UnmarshalMultipartPresence is the same as UnmarshalMultipart but also returns the field paths of the
+// supplied values and files, see UnmarshalUrlPresence
+func UnmarshalMultipartPresence(form *multipart.Form, v interface{}) (Presence, error) {
+	return unmarshalMultipart(form, v, true)
+}
+
+func unmarshalMultipart(form *multipart.Form, v interface{}, presence bool) (Presence, error) {
+	var rv reflect.Value
+	if rev, ok := v.(reflect.Value); ok {
+		rv = rev
+	} else {
+		rv = reflect.ValueOf(v)
+	}
+	var p Presence
+	if presence {
+		p = make(Presence)
+	}
+	err := unmarshalUrl(form.Value, form.File, &rv, "", p)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// isFileType reports whether t is a File type or a slice of File type
+func isFileType(t reflect.Type) bool {
+	if GoToJSONType(t) == File {
+		return true
+	}
+	t = indirectType(t)
+	return t.Kind() == reflect.Slice && GoToJSONType(t.Elem()) == File
+}
+
+func setFileValue(headers []*multipart.FileHeader, vp *reflect.Value) error {
+	var v = *vp
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if GoToJSONType(v.Type()) == File {
+		return readFile(headers[0], v)
+	}
+	items := reflect.MakeSlice(v.Type(), len(headers), len(headers))
+	for i, header := range headers {
+		item := items.Index(i)
+		if item.Kind() == reflect.Ptr {
+			item.Set(reflect.New(item.Type().Elem()))
+			item = item.Elem()
+		}
+		err := readFile(header, item)
+		if err != nil {
+			return err
+		}
+	}
+	v.Set(items)
+	return nil
+}
+
+// readFile reads the file by the FileInterface of the addressable v
+func readFile(header *multipart.FileHeader, v reflect.Value) error {
+	return v.Addr().Interface().(FileInterface).Read(*header)
+}
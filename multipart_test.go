@@ -0,0 +1,62 @@
+// Copyright 2020 orivil.com. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found at https://mit-license.org.
+
+package schema_test
+
+import (
+	"github.com/orivil/schema"
+	"io/ioutil"
+	"testing"
+)
+
+func TestUnmarshalMultipart(t *testing.T) {
+	type upload struct {
+		Title  string            `json:"title"`
+		Tags   []string          `json:"tags"`
+		Avatar schema.FileData   `json:"avatar"`
+		Doc    *schema.FormFile  `json:"doc"`
+		Photos []schema.FileData `json:"photos"`
+		Other  schema.FileData   `json:"other"`
+	}
+	form := newMultipartForm(map[string][]string{
+		"title": {"Nina"},
+		"tags":  {"a", "b"},
+	}, map[string][2]string{
+		"avatar": {"avatar.png", "png"},
+		"doc":    {"doc.md", "# title"},
+		"photos": {"photo.jpg", "jpg"},
+	})
+	v := &upload{}
+	p, err := schema.UnmarshalMultipartPresence(form, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Title != "Nina" || len(v.Tags) != 2 || string(v.Avatar) != "png" || len(v.Photos) != 1 ||
+		string(v.Photos[0]) != "jpg" || v.Other != nil {
+		t.Fatalf("got: %s", jsonStr(v))
+	}
+	if v.Doc == nil || v.Doc.Name() != "doc.md" {
+		t.Fatal("need the header of doc.md")
+	}
+	rc, err := v.Doc.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	data, err := ioutil.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "# title" {
+		t.Fatalf("need: # title\ngot: %s", data)
+	}
+	for _, field := range []string{"title", "tags", "avatar", "doc", "photos"} {
+		if !p.Has(field) {
+			t.Errorf("need presence of %s", field)
+		}
+	}
+	if p.Has("other") {
+		t.Error("need no presence of other")
+	}
+}
@@ -0,0 +1,316 @@
+// Copyright 2020 orivil.com. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found at https://mit-license.org.
+
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const OpenAPIVersion = "3.1.0"
+
+// OpenAPI generates an OpenAPI 3.1 document which contains the "components" section
+type OpenAPI struct {
+	Title         string
+	Version       string
+	schemas       map[string]*Schema
+	queries       map[string]*Schema
+	requestBodies map[string]*Schema
+}
+
+func NewOpenAPI(title, version string) *OpenAPI {
+	return &OpenAPI{
+		Title:         title,
+		Version:       version,
+		schemas:       make(map[string]*Schema),
+		queries:       make(map[string]*Schema),
+		requestBodies: make(map[string]*Schema),
+	}
+}
+
+// AddSchema adds s to "components.schemas", the invalid characters of the name are replaced by "_" as
+// the names of the models
+func (o *OpenAPI) AddSchema(name string, s *Schema) *OpenAPI {
+	o.schemas[componentName(name)] = s
+	return o
+}
+
+// AddQuery adds every property of s to "components.parameters" as a query parameter named by
+// name + "." + property, s should be the schema of a struct which is used with UnmarshalUrl
+func (o *OpenAPI) AddQuery(name string, s *Schema) *OpenAPI {
+	o.queries[name] = s
+	return o
+}
+
+// AddRequestBody adds s to "components.requestBodies", the media type is "multipart/form-data" if s
+// contains File properties, otherwise "application/json"
+func (o *OpenAPI) AddRequestBody(name string, s *Schema) *OpenAPI {
+	o.requestBodies[name] = s
+	return o
+}
+
+var invalidComponentName = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// Document returns the OpenAPI document
+func (o *OpenAPI) Document() map[string]interface{} {
+	e := &jsonSchemaExporter{
+		defs:      make(map[string]interface{}),
+		refPrefix: "#/components/schemas/",
+		names:     make(map[string]string),
+		models:    make(Models),
+	}
+	for name, s := range o.schemas {
+		if s.Model != "" {
+			e.names[modelRef(s.Namespace, s.Model)] = name
+		}
+	}
+	for _, all := range []map[string]*Schema{o.schemas, o.queries, o.requestBodies} {
+		for _, s := range all {
+			for ref, model := range s.models {
+				if _, ok := e.models[ref]; !ok {
+					e.models[ref] = model
+				}
+			}
+		}
+	}
+	for _, all := range []map[string]*Schema{o.schemas, o.queries, o.requestBodies} {
+		for _, s := range all {
+			o.nameModels(e, s)
+		}
+	}
+	for name, s := range o.schemas {
+		if s.Model != "" {
+			e.export(s)
+		} else {
+			e.defs[name] = e.export(s)
+		}
+	}
+	components := make(map[string]interface{})
+	parameters := make(map[string]interface{})
+	for name, s := range o.queries {
+		for _, property := range s.Properties {
+			parameters[componentName(name+"."+property.Name)] = o.parameter(e, property)
+		}
+	}
+	if len(parameters) > 0 {
+		components["parameters"] = parameters
+	}
+	requestBodies := make(map[string]interface{})
+	for name, s := range o.requestBodies {
+		mediaType := "application/json"
+		if containsFile(s) {
+			mediaType = "multipart/form-data"
+		}
+		requestBodies[componentName(name)] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				mediaType: map[string]interface{}{"schema": e.export(s)},
+			},
+		}
+	}
+	if len(requestBodies) > 0 {
+		components["requestBodies"] = requestBodies
+	}
+	if len(e.defs) > 0 {
+		components["schemas"] = e.defs
+	}
+	return map[string]interface{}{
+		"openapi":    OpenAPIVersion,
+		"info":       map[string]interface{}{"title": o.Title, "version": o.Version},
+		"components": components,
+	}
+}
+
+// nameModels gives every model a valid component name
+func (o *OpenAPI) nameModels(e *jsonSchemaExporter, s *Schema) {
+	ref := s.Ref
+	if s.Model != "" {
+		ref = modelRef(s.Namespace, s.Model)
+	}
+	if _, ok := e.names[ref]; ref != "" && !ok {
+		e.names[ref] = componentName(ref)
+	}
+	if s.Items != nil {
+		o.nameModels(e, s.Items)
+	}
+	if s.AdditionalProperties != nil {
+		o.nameModels(e, s.AdditionalProperties)
+	}
+	for _, property := range s.Properties {
+		o.nameModels(e, property)
+	}
+}
+
+func (o *OpenAPI) parameter(e *jsonSchemaExporter, s *Schema) map[string]interface{} {
+	param := map[string]interface{}{
+		"name": s.Name,
+		"in":   "query",
+	}
+	if s.Description != "" {
+		param["description"] = s.Description
+	}
+	if s.Validations != nil && s.Validations.Required {
+		param["required"] = true
+	}
+	schema := e.export(s)
+	delete(schema, "description")
+	switch s.Type {
+	case Object:
+		// a struct parameter is decoded from a url encoded value, see UnmarshalUrl
+		param["content"] = map[string]interface{}{
+			"application/x-www-form-urlencoded": map[string]interface{}{"schema": schema},
+		}
+	case Array:
+		param["style"] = "form"
+		param["explode"] = true
+		param["schema"] = schema
+	default:
+		param["schema"] = schema
+	}
+	return param
+}
+
+func componentName(name string) string {
+	return invalidComponentName.ReplaceAllString(name, "_")
+}
+
+func containsFile(s *Schema) bool {
+	if s.Type == File {
+		return true
+	}
+	if s.Items != nil && containsFile(s.Items) {
+		return true
+	}
+	if s.AdditionalProperties != nil && containsFile(s.AdditionalProperties) {
+		return true
+	}
+	for _, property := range s.Properties {
+		if containsFile(property) {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalJSON returns the JSON encoding of the document
+func (o *OpenAPI) MarshalJSON() ([]byte, error) {
+	return json.Marshal(o.Document())
+}
+
+// MarshalYAML returns the YAML encoding of the document
+func (o *OpenAPI) MarshalYAML() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	err := writeYAML(buf, o.Document(), 0)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var plainYAMLKey = regexp.MustCompile(`^[a-zA-Z_$][a-zA-Z0-9_.$/-]*$`)
+
+// writeYAML writes the value of a map or a slice with block style, v should be a JSON compatible value
+func writeYAML(buf *bytes.Buffer, v interface{}, indent int) error {
+	prefix := strings.Repeat("  ", indent)
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(vv))
+		for key := range vv {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for i, key := range keys {
+			// the first key of a map in a list follows the "- "
+			if i > 0 || buf.Len() == 0 || buf.Bytes()[buf.Len()-1] == '\n' {
+				buf.WriteString(prefix)
+			}
+			if plainYAMLKey.MatchString(key) {
+				buf.WriteString(key)
+			} else {
+				buf.WriteString(strconv.Quote(key))
+			}
+			buf.WriteByte(':')
+			err := writeYAMLValue(buf, vv[key], indent)
+			if err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range vv {
+			buf.WriteString(prefix)
+			buf.WriteString("-")
+			var err error
+			if m, ok := item.(map[string]interface{}); ok && len(m) > 0 {
+				buf.WriteByte(' ')
+				err = writeYAML(buf, item, indent+1)
+			} else {
+				err = writeYAMLValue(buf, item, indent)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported YAML block value %T", v)
+	}
+	return nil
+}
+
+func writeYAMLValue(buf *bytes.Buffer, v interface{}, indent int) error {
+	switch vv := v.(type) {
+	case []string:
+		items := make([]interface{}, len(vv))
+		for i, item := range vv {
+			items[i] = item
+		}
+		v = items
+	}
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		if len(vv) == 0 {
+			buf.WriteString(" {}\n")
+			return nil
+		}
+	case []interface{}:
+		if len(vv) == 0 {
+			buf.WriteString(" []\n")
+			return nil
+		}
+	default:
+		buf.WriteByte(' ')
+		err := writeYAMLScalar(buf, v)
+		if err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+		return nil
+	}
+	buf.WriteByte('\n')
+	return writeYAML(buf, v, indent+1)
+}
+
+func writeYAMLScalar(buf *bytes.Buffer, v interface{}) error {
+	switch vv := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case string:
+		// the escape sequences of Go are a subset of YAML double-quoted style
+		buf.WriteString(strconv.Quote(vv))
+	case bool:
+		buf.WriteString(strconv.FormatBool(vv))
+	case int:
+		buf.WriteString(strconv.Itoa(vv))
+	case float64:
+		buf.WriteString(strconv.FormatFloat(vv, 'g', -1, 64))
+	default:
+		return fmt.Errorf("unsupported YAML scalar value %T", v)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+// Copyright 2020 orivil.com. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found at https://mit-license.org.
+
+package schema_test
+
+import (
+	"github.com/orivil/schema"
+	"strings"
+	"testing"
+)
+
+type ListQuery struct {
+	Page  int      `json:"page" schema:"required;minNum:1" desc:"page number"`
+	Tags  []string `json:"tags"`
+	Owner *Owner   `json:"owner"`
+}
+
+type Owner struct {
+	Name string `json:"name" schema:"required"`
+}
+
+type UploadForm struct {
+	Title  string          `json:"title" schema:"maxLen:20"`
+	Avatar schema.FileData `json:"avatar" schema:"required"`
+}
+
+func TestOpenAPI(t *testing.T) {
+	owner, err := schema.NewSchema(&Owner{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	query, err := schema.NewSchema(&ListQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	form, err := schema.NewSchema(&UploadForm{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := schema.NewOpenAPI("demo", "1.0.0").
+		AddSchema("Owner", owner).
+		AddQuery("ListQuery", query).
+		AddRequestBody("Upload", form)
+	data, err := api.MarshalYAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	need := `components:
+  parameters:
+    ListQuery.owner:
+      content:
+        application/x-www-form-urlencoded:
+          schema:
+            $ref: "#/components/schemas/Owner"
+      in: "query"
+      name: "owner"
+    ListQuery.page:
+      description: "page number"
+      in: "query"
+      name: "page"
+      required: true
+      schema:
+        minimum: 1
+        type: "number"
+    ListQuery.tags:
+      explode: true
+      in: "query"
+      name: "tags"
+      schema:
+        items:
+          type: "string"
+        type: "array"
+      style: "form"
+  requestBodies:
+    Upload:
+      content:
+        multipart/form-data:
+          schema:
+            $ref: "#/components/schemas/github.com_orivil_schema_test.UploadForm"
+      required: true
+  schemas:
+    Owner:
+      properties:
+        name:
+          type: "string"
+      required:
+        - "name"
+      type: "object"
+    github.com_orivil_schema_test.UploadForm:
+      properties:
+        avatar:
+          contentMediaType: "application/octet-stream"
+          type: "string"
+        title:
+          maxLength: 20
+          type: "string"
+      required:
+        - "avatar"
+      type: "object"
+info:
+  title: "demo"
+  version: "1.0.0"
+openapi: "3.1.0"
+`
+	if got != need {
+		t.Fatalf("need: %s\ngot: %s", need, got)
+	}
+	data, err = api.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, need := string(data), jsonCompact(api.Document()); got != need {
+		t.Fatalf("need: %s\ngot: %s", need, got)
+	}
+}
+
+func TestOpenAPIComponentName(t *testing.T) {
+	type pet struct {
+		Owner Owner `json:"owner"`
+	}
+	s, err := schema.NewSchema(&pet{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := schema.NewOpenAPI("demo", "1.0.0").
+		AddSchema("pet owner", s.Property("owner")).
+		AddRequestBody("Pet", s).
+		Document()
+	schemas := doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	if _, ok := schemas["pet_owner"]; !ok {
+		t.Fatalf("need the component pet_owner, got: %s", jsonCompact(schemas))
+	}
+	got := jsonCompact(schemas["github.com_orivil_schema_test.pet"])
+	if need := `{"properties":{"owner":{"$ref":"#/components/schemas/pet_owner"}},"type":"object"}`; got != need {
+		t.Fatalf("need: %s\ngot: %s", need, got)
+	}
+}
+
+func TestOpenAPIConditions(t *testing.T) {
+	type contact struct {
+		Email string `json:"email"`
+		Phone string `json:"phone" schema:"requiredWith:email"`
+	}
+	s, err := schema.NewSchema(&contact{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := schema.NewOpenAPI("demo", "1.0.0").AddSchema("Contact", s).MarshalYAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	need := `    Contact:
+      dependentRequired:
+        email:
+          - "phone"
+`
+	if !strings.Contains(string(data), need) {
+		t.Fatalf("need: %s\ngot: %s", need, data)
+	}
+}
@@ -16,20 +16,28 @@ type Decoder interface {
 
 var decoderType = reflect.TypeOf(new(Decoder)).Elem()
 
-func valueToSchema(v reflect.Value, existStructs map[reflect.Type]struct{}) (*Schema, error) {
+// valueToSchema builds the schema of v, every struct model is put into models
+func valueToSchema(v reflect.Value, existStructs map[reflect.Type]struct{}, models Models) (*Schema, error) {
 	if !v.IsValid() {
 		return nil, nil
 	}
 	if v.Type().Implements(decoderType) {
-		return v.Interface().(Decoder).Schema(), nil
+		schema := v.Interface().(Decoder).Schema()
+		if schema != nil {
+			// the Ref schemas of the decoder are resolved by the models of the root
+			for ref, model := range schema.models {
+				models[ref] = model
+			}
+		}
+		return schema, nil
 	}
 	v = indirectValue(v, true)
 	t := v.Type()
-	schema := &Schema{Type: GoToJSONType(t)}
+	schema := &Schema{Type: GoToJSONType(t), unmarshaler: isUnmarshaler(t), bytes: isBytes(t)}
 	k := t.Kind()
 	switch k {
 	case reflect.Interface:
-		return valueToSchema(v.Elem(), existStructs)
+		return valueToSchema(v.Elem(), existStructs, models)
 	case reflect.Slice, reflect.Array:
 		if schema.Type != File {
 			ln := v.Len()
@@ -38,7 +46,7 @@ func valueToSchema(v reflect.Value, existStructs map[reflect.Type]struct{}) (*Sc
 				err   error
 			)
 			if ln == 0 { // nil slice
-				items, err = valueToSchema(reflect.New(t.Elem()), existStructs)
+				items, err = valueToSchema(reflect.New(t.Elem()), existStructs, models)
 			} else {
 				for i := 0; i < ln-1; i++ {
 					pre := indirectType(v.Index(i).Type())
@@ -47,7 +55,7 @@ func valueToSchema(v reflect.Value, existStructs map[reflect.Type]struct{}) (*Sc
 						return nil, fmt.Errorf("slice or array element type must be unique, got %s, and %s", pre, next)
 					}
 				}
-				items, err = valueToSchema(v.Index(0), existStructs)
+				items, err = valueToSchema(v.Index(0), existStructs, models)
 			}
 			if err != nil {
 				return nil, err
@@ -57,12 +65,16 @@ func valueToSchema(v reflect.Value, existStructs map[reflect.Type]struct{}) (*Sc
 			}
 		}
 	case reflect.Struct:
+		if schema.Type == File {
+			break
+		}
 		if _, ok := existStructs[t]; ok {
-			return &Schema{Ref: t.PkgPath() + "." + t.Name()}, nil
+			return &Schema{Ref: modelRef(t.PkgPath(), t.Name())}, nil
 		} else {
 			schema.Model = t.Name()
 			schema.Namespace = t.PkgPath()
 			existStructs[t] = struct{}{}
+			models[modelRef(schema.Namespace, schema.Model)] = schema
 		}
 		schema.Properties = Properties{}
 		fields := getStructFields(v)
@@ -70,24 +82,41 @@ func valueToSchema(v reflect.Value, existStructs map[reflect.Type]struct{}) (*Sc
 			if ignore := isFieldIgnored(field.ft.Tag); ignore {
 				continue
 			}
-			fs, err := valueToSchema(field.fv, existStructs)
+			fs, err := valueToSchema(field.fv, existStructs, models)
 			if err != nil {
 				return nil, err
 			}
 			if fs != nil {
+				fs = fs.propertyOf()
 				fs.Name = field.property
 				err = fs.WithTagOptions(field.ft.Tag)
+				if err == nil {
+					err = fs.checkExt(field.ft.Type)
+				}
 				if err != nil {
 					return nil, err
 				}
 				schema.Properties = append(schema.Properties, fs)
 			}
 		}
+		err := schema.checkSiblings()
+		if err != nil {
+			return nil, err
+		}
 	case reflect.Map:
+		values, err := valueToSchema(reflect.New(t.Elem()), existStructs, models)
+		if err != nil {
+			return nil, err
+		}
+		if values == nil {
+			// the values of any type, such as the values of a map[string]interface{}
+			values = &Schema{}
+		}
+		schema.AdditionalProperties = values
 		keys := v.MapKeys()
 		for _, key := range keys {
 			mv := v.MapIndex(key)
-			ms, err := valueToSchema(mv, existStructs)
+			ms, err := valueToSchema(mv, existStructs, models)
 			if err != nil {
 				return nil, err
 			}
@@ -109,6 +138,20 @@ func valueToSchema(v reflect.Value, existStructs map[reflect.Type]struct{}) (*Sc
 	return schema, nil
 }
 
+// propertyOf returns the schema of a property whose type is s, a model is copied so that the tag options
+// of the property do not change the rules of the model, which are used by the Ref schemas
+func (s *Schema) propertyOf() *Schema {
+	if s.Model == "" {
+		return s
+	}
+	ps := *s
+	if s.Validations != nil {
+		vs := *s.Validations
+		ps.Validations = &vs
+	}
+	return &ps
+}
+
 func indirectValue(v reflect.Value, newEmpty bool) reflect.Value {
 	if v.Kind() == reflect.Ptr {
 		if newEmpty && v.IsNil() {
@@ -132,6 +175,8 @@ type structField struct {
 	property string
 	fv       reflect.Value
 	ft       reflect.StructField
+	// the field value before indirect
+	raw reflect.Value
 }
 
 // get struct fields and merge anonymous fields
@@ -157,6 +202,7 @@ func getStructFields(v reflect.Value) []*structField {
 					property: property,
 					fv:       indirectValue(fv, true),
 					ft:       ft,
+					raw:      fv,
 				}
 				fields = append(fields, sf)
 				exists[ft.Name] = struct{}{}
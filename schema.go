@@ -5,9 +5,11 @@
 package schema
 
 import (
+	"fmt"
 	"github.com/orivil/types"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 )
@@ -22,110 +24,493 @@ type Schema struct {
 	Items       *Schema      `json:"items,omitempty"`
 	Properties  Properties   `json:"properties,omitempty"`
 	Validations *Validations `json:"validations,omitempty"`
+	// AdditionalProperties is the schema of the map values whose keys are not the properties
+	AdditionalProperties *Schema `json:"additionalProperties,omitempty"`
+	// models for resolving the Ref schemas
+	models Models
+	// unmarshaler reports whether the Go type decodes the JSON values itself, such as time.Time, whose
+	// JSON kind is not checked
+	unmarshaler bool
+	// bytes reports whether the Go type is a slice of bytes, which is also decoded from a base64 JSON
+	// string
+	bytes bool
 }
 
+// Models holds the struct models keyed by the reference name, which is the same as Schema.Ref
 type Models map[string]*Schema
 
 func (ms Models) GetSchema(ref string) *Schema {
 	return ms[ref]
 }
 
-func NewSchema(v interface{}) (*Schema, error) {
+// NewSchema builds the schema of v and puts every struct model into ms, the Ref schemas are resolved
+// through ms when validating
+func (ms Models) NewSchema(v interface{}) (*Schema, error) {
 	rv := reflect.ValueOf(v)
-	schema, err := valueToSchema(rv, make(map[reflect.Type]struct{}))
+	schema, err := valueToSchema(rv, make(map[reflect.Type]struct{}), ms)
 	if err != nil {
 		return nil, err
 	}
+	if schema != nil {
+		schema.models = ms
+	}
 	return schema, nil
 }
 
-func (s *Schema) Valid(v interface{}) (info *Validations, err error) {
-	return s.valid("", reflect.ValueOf(v))
+func NewSchema(v interface{}) (*Schema, error) {
+	return make(Models).NewSchema(v)
+}
+
+// WithModels sets the models for resolving the Ref schemas
+func (s *Schema) WithModels(ms Models) *Schema {
+	s.models = ms
+	return s
+}
+
+// Models returns the models for resolving the Ref schemas
+func (s *Schema) Models() Models {
+	return s.models
+}
+
+// ValidOption is an option of validating, see Schema.Valid
+type ValidOption func(vr *validator)
+
+// WithPresence sets the field paths of the supplied values, which is used by the "required:present" rule
+// for the fields that are not pointers, see UnmarshalUrlPresence and JSONDecoder.Presence
+func WithPresence(p Presence) ValidOption {
+	return func(vr *validator) {
+		vr.presence = p
+	}
+}
+
+// AsError makes the failures returned as the error, Schema.Valid returns a *ValidationError and
+// Schema.ValidAll returns a ValidationErrors
+func AsError() ValidOption {
+	return func(vr *validator) {
+		vr.asError = true
+	}
+}
+
+func (s *Schema) Valid(v interface{}, opts ...ValidOption) (info *Validations, err error) {
+	vr := s.newValidator(false, opts)
+	err = s.valid(vr, "", reflect.ValueOf(v))
+	if err != nil {
+		return nil, err
+	}
+	if len(vr.failures) > 0 {
+		if vr.asError {
+			return nil, vr.failures[0]
+		}
+		return vr.failures[0].validations, nil
+	}
+	return nil, nil
+}
+
+// ValidAll walks every property, array item and nested object of v and returns all the failures,
+// each failure holds the field path and the failed rule, see Validations.Rule
+func (s *Schema) ValidAll(v interface{}, opts ...ValidOption) (infos []*Validations, err error) {
+	vr := s.newValidator(true, opts)
+	err = s.valid(vr, "", reflect.ValueOf(v))
+	if err != nil {
+		return nil, err
+	}
+	return vr.result()
+}
+
+func (s *Schema) newValidator(all bool, opts []ValidOption) *validator {
+	vr := &validator{all: all, models: s.models}
+	for _, opt := range opts {
+		opt(vr)
+	}
+	return vr
+}
+
+type validator struct {
+	all      bool
+	asError  bool
+	models   Models
+	presence Presence
+	// strict makes every object reject the unknown properties, see Strict
+	strict bool
+	// absentRefs holds the models which are walked for the absent values, see refSchema
+	absentRefs map[string]struct{}
+	// object holds the siblings of the properties being validated, root holds the properties of the
+	// root value
+	object, root *objectScope
+	// requiredMode and lenUnit are inherited from the parent schemas
+	requiredMode string
+	lenUnit      string
+	failures     []*ValidationError
+}
+
+func (vr *validator) fail(s *Schema, field string, info *Validations, actual interface{}) {
+	if field != "" {
+		info.Field = field
+	}
+	e := newValidationError(info, actual)
+	e.Description = s.Description
+	if s.Validations != nil {
+		e.Message = s.Validations.Message
+	}
+	vr.failures = append(vr.failures, e)
+}
+
+// result returns all the failures
+func (vr *validator) result() ([]*Validations, error) {
+	if len(vr.failures) == 0 {
+		return nil, nil
+	}
+	if vr.asError {
+		return nil, ValidationErrors(vr.failures)
+	}
+	infos := make([]*Validations, len(vr.failures))
+	for i, failure := range vr.failures {
+		infos[i] = failure.validations
+	}
+	return infos, nil
+}
+
+// stopped reports whether the validation should stop walking
+func (vr *validator) stopped() bool {
+	return !vr.all && len(vr.failures) > 0
 }
 
-func (s *Schema) valid(field string, v reflect.Value) (info *Validations, err error) {
-	defer func() {
-		if info != nil && field != "" {
-			info.Field = field
+func (vr *validator) getRequiredMode(s *Schema) string {
+	if s.Validations != nil && s.Validations.RequiredMode != "" {
+		return s.Validations.RequiredMode
+	}
+	if vr.requiredMode != "" {
+		return vr.requiredMode
+	}
+	return RequiredNonzero
+}
+
+// inherit makes the properties inherit the modes of the object, it returns a function for restoring
+func (vr *validator) inherit(vs *Validations) (restore func()) {
+	requiredMode, lenUnit := vr.requiredMode, vr.lenUnit
+	if vs.RequiredMode != "" {
+		vr.requiredMode = vs.RequiredMode
+	}
+	if vs.LenUnit != "" {
+		vr.lenUnit = vs.LenUnit
+	}
+	return func() {
+		vr.requiredMode, vr.lenUnit = requiredMode, lenUnit
+	}
+}
+
+func (vr *validator) getLenUnit(s *Schema) string {
+	if s.Validations != nil && s.Validations.LenUnit != "" {
+		return s.Validations.LenUnit
+	}
+	if vr.lenUnit != "" {
+		return vr.lenUnit
+	}
+	return LenUnitByte
+}
+
+// supplied reports whether the value v of the field is supplied under the required mode of s
+func (vr *validator) supplied(s *Schema, field string, v reflect.Value) bool {
+	if vr.getRequiredMode(s) == RequiredPresent {
+		if isNilValue(v) {
+			return false
 		}
-	}()
-	if s.Validations != nil && s.Type != Object {
-		v = reflect.Indirect(v)
-		valid := v.IsValid() && !v.IsZero()
-		if s.Validations.Required && !valid {
-			return &Validations{Required: true}, nil
+		switch v.Kind() {
+		case reflect.Ptr, reflect.Interface:
+			return true
 		}
-		if valid {
-			switch s.Type {
-			case String, Number:
-				var tv types.Value
-				tv, err = types.GetValue(v.Interface())
+		if vr.presence != nil {
+			return vr.presence.Has(field)
+		}
+	}
+	v = reflect.Indirect(v)
+	return v.IsValid() && !v.IsZero()
+}
+
+func (s *Schema) valid(vr *validator, field string, v reflect.Value) (err error) {
+	if s.Ref != "" {
+		return s.validRef(vr, field, v)
+	}
+	if iv := indirectInterface(v); !s.unmarshaler && !isJSONKind(s.Type, iv) && !(s.bytes && isJSONKind(String, iv)) {
+		vr.fail(s, field, &Validations{Type: s.Type}, valueInterface(v))
+		return nil
+	}
+	if s.Validations != nil {
+		actual := valueInterface(v)
+		length := 0
+		if s.Type == Array {
+			length = lengthOf(actual)
+		}
+		var ok bool
+		ok, err = s.check(vr, field, actual, length, vr.supplied(s, field, v))
+		if err != nil || !ok {
+			return err
+		}
+		if s.Type == Object {
+			defer vr.inherit(s.Validations)()
+		}
+	}
+	if s.Type == Object && field != "" && vr.getRequiredMode(s) == RequiredPresent && isNilValue(indirectInterface(v)) {
+		// the properties of an absent object are not required
+		return nil
+	}
+	switch s.Type {
+	case Array:
+		v = indirectInterface(reflect.Indirect(v))
+		if s.Items != nil && v.IsValid() && (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) {
+			ln := v.Len()
+			for i := 0; i < ln && !vr.stopped(); i++ {
+				err = s.Items.valid(vr, initItemName(field, i), v.Index(i))
 				if err != nil {
-					return nil, err
-				}
-				switch s.Type {
-				case String:
-					info, err = s.Validations.validString(tv.String())
-					if err != nil || info != nil {
-						return info, err
-					}
-				case Number:
-					var num float64
-					num, err = tv.Float64()
-					if err != nil {
-						return nil, err
-					}
-					info, err = s.Validations.validNumber(num)
-					if err != nil || info != nil {
-						return info, err
-					}
-				}
-			case Array:
-				var ln = v.Len()
-				info = s.Validations.validItemsLength(ln)
-				if info != nil {
-					return info, nil
-				}
-				for i := 0; i < ln; i++ {
-					var item = v.Index(i)
-					info, err = s.Items.valid(field, item)
-					if err != nil {
-						return nil, err
-					}
-					if info != nil {
-						return info, nil
-					}
+					return err
 				}
 			}
 		}
-	}
-	if s.Type == Object {
-		v = indirectValue(v, true)
+	case Object:
+		absent := isNilValue(indirectInterface(v))
+		v = indirectValue(indirectInterface(v), true)
 		vk := v.Kind()
 		if vk == reflect.Struct {
 			fs := getStructFields(v)
 			fvs := make(map[string]reflect.Value, len(fs))
 			for _, f := range fs {
-				fvs[f.property] = f.fv
+				fvs[f.property] = f.raw
+			}
+			defer vr.enter(field, s, fvs)()
+			failures := len(vr.failures)
+			err = s.validUnknown(vr, field, v)
+			if err != nil || vr.stopped() {
+				return err
 			}
 			for _, schema := range s.Properties {
 				fv := fvs[schema.Name]
-				info, err = schema.valid(initFieldName(field, schema.Name), fv)
-				if info != nil || err != nil {
-					return info, err
+				err = schema.valid(vr, initFieldName(field, schema.Name), fv)
+				if err != nil || vr.stopped() {
+					return err
 				}
 			}
+			if !absent && len(vr.failures) == failures {
+				vr.validSchema(s, field, v)
+			}
 		} else if vk == reflect.Map {
+			fvs := make(map[string]reflect.Value, len(s.Properties))
 			for _, schema := range s.Properties {
-				fv := v.MapIndex(reflect.ValueOf(schema.Name))
-				info, err = schema.valid(initFieldName(field, schema.Name), fv)
-				if info != nil || err != nil {
-					return info, err
+				fvs[schema.Name] = v.MapIndex(reflect.ValueOf(schema.Name))
+			}
+			defer vr.enter(field, s, fvs)()
+			err = s.validUnknown(vr, field, v)
+			if err != nil || vr.stopped() {
+				return err
+			}
+			for _, schema := range s.Properties {
+				fv := fvs[schema.Name]
+				err = schema.valid(vr, initFieldName(field, schema.Name), fv)
+				if err != nil || vr.stopped() {
+					return err
 				}
 			}
+			if s.AdditionalProperties != nil {
+				return s.validAdditional(vr, field, v)
+			}
 		}
 	}
-	return nil, nil
+	return nil
+}
+
+// check checks the rules of s except the rules of the items and properties, actual is the indirect value
+// of the field and length is the length of the array, it reports whether the walking could go on. It
+// does not use reflection for the built-in types so it is shared by the generated code, see Checker
+func (s *Schema) check(vr *validator, field string, actual interface{}, length int, supplied bool) (ok bool, err error) {
+	vs := s.Validations
+	if vs.Required && !supplied {
+		vr.fail(s, field, &Validations{Required: true}, actual)
+		return false, nil
+	}
+	if !supplied && vs.hasSiblingRules() {
+		if info := vr.requiredBy(vs); info != nil {
+			vr.fail(s, field, info, actual)
+			return false, nil
+		}
+	}
+	if s.Type == Bool && vs.Const != nil {
+		// the rule is checked for false values which are not supplied under the nonzero mode
+		if b, isBool := boolValue(actual); isBool && b != *vs.Const {
+			vr.fail(s, field, &Validations{Const: vs.Const}, actual)
+			return false, nil
+		}
+	}
+	if supplied && s.Type != Object {
+		var info *Validations
+		switch s.Type {
+		case String, Number:
+			var tv types.Value
+			tv, err = types.GetValue(actual)
+			if err != nil {
+				return false, err
+			}
+			switch s.Type {
+			case String:
+				info, err = vs.validString(tv.String(), vr.getLenUnit(s))
+			case Number:
+				var num float64
+				num, err = tv.Float64()
+				if err != nil {
+					return false, err
+				}
+				info, err = vs.validNumber(num)
+			}
+		case Array:
+			info = vs.validItemsLength(length)
+		case File:
+			info, err = vs.validFile(actual)
+		}
+		if err != nil {
+			return false, err
+		}
+		if info != nil {
+			vr.fail(s, field, info, actual)
+			return false, nil
+		}
+	}
+	if supplied && s.Type == Object && vs.isPropertiesValidations() {
+		var (
+			info *Validations
+			key  interface{}
+		)
+		info, key, err = vs.validProperties(actual, vr.getLenUnit(s))
+		if err != nil {
+			return false, err
+		}
+		if info != nil {
+			vr.fail(s, field, info, key)
+			return false, nil
+		}
+	}
+	if supplied && vs.hasFieldRules() {
+		var info *Validations
+		info, err = vr.compareFields(vs, actual)
+		if err != nil {
+			return false, err
+		}
+		if info != nil {
+			vr.fail(s, field, info, actual)
+			return false, nil
+		}
+	}
+	if supplied && len(vs.Use) > 0 {
+		if name := validByValidators(vs.Use, actual); name != "" {
+			vr.fail(s, field, &Validations{Use: []string{name}}, actual)
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func boolValue(v interface{}) (b bool, ok bool) {
+	if b, ok = v.(bool); ok {
+		return b, ok
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Bool {
+		return rv.Bool(), true
+	}
+	return false, false
+}
+
+// validRef validates v by the model of the reference with the rules of s, an absent value is walked as the
+// zero value the same as the inline objects, see validator.refSchema
+func (s *Schema) validRef(vr *validator, field string, v reflect.Value) error {
+	ms, walk, restore := vr.refSchema(s, isNilValue(indirectInterface(v)))
+	defer restore()
+	if !walk {
+		ms.Properties, ms.AdditionalProperties = nil, nil
+	}
+	return ms.valid(vr, field, v)
+}
+
+// refSchema returns the model of the Ref schema s with the rules of s, which override the rules of the
+// model. An absent value of a model is only walked once on the path so that the walking of the
+// self-referencing models ends, walk reports whether the properties should be walked and restore ends
+// the walking
+func (vr *validator) refSchema(s *Schema, absent bool) (ms *Schema, walk bool, restore func()) {
+	restore = func() {}
+	model := vr.models.GetSchema(s.Ref)
+	if model == nil {
+		return &Schema{Name: s.Name, Description: s.Description, Validations: s.Validations}, false, restore
+	}
+	ms = &Schema{
+		Name:                 s.Name,
+		Type:                 model.Type,
+		Description:          model.Description,
+		Items:                model.Items,
+		Properties:           model.Properties,
+		AdditionalProperties: model.AdditionalProperties,
+		Validations:          mergeValidations(model.Validations, s.Validations),
+		unmarshaler:          model.unmarshaler,
+	}
+	if s.Description != "" {
+		ms.Description = s.Description
+	}
+	if !absent {
+		return ms, true, restore
+	}
+	if _, ok := vr.absentRefs[s.Ref]; ok {
+		return ms, false, restore
+	}
+	if vr.absentRefs == nil {
+		vr.absentRefs = make(map[string]struct{})
+	}
+	vr.absentRefs[s.Ref] = struct{}{}
+	return ms, true, func() {
+		delete(vr.absentRefs, s.Ref)
+	}
+}
+
+// mergeValidations returns the rules of base overridden by the rules which are set in vs
+func mergeValidations(base, vs *Validations) *Validations {
+	if base == nil || vs == nil {
+		if base == nil {
+			return vs
+		}
+		return base
+	}
+	merged := *base
+	mv, v := reflect.ValueOf(&merged).Elem(), reflect.ValueOf(vs).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if f := v.Field(i); !f.IsZero() {
+			mv.Field(i).Set(f)
+		}
+	}
+	return &merged
+}
+
+func isNilValue(v reflect.Value) bool {
+	if !v.IsValid() {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		return v.IsNil()
+	}
+	return false
+}
+
+// valueInterface returns the indirect value of v as an interface{}, or nil if v is nil
+func valueInterface(v reflect.Value) interface{} {
+	v = indirectInterface(reflect.Indirect(v))
+	if isNilValue(v) || !v.CanInterface() || v.Kind() == reflect.Interface {
+		return nil
+	}
+	return v.Interface()
+}
+
+// indirectInterface returns the dynamic value of the interface value v, values in a
+// map[string]interface{} are interface values
+func indirectInterface(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Interface && !v.IsNil() {
+		v = reflect.Indirect(v.Elem())
+	}
+	return v
 }
 
 func initFieldName(parent, field string) string {
@@ -136,6 +521,10 @@ func initFieldName(parent, field string) string {
 	}
 }
 
+func initItemName(parent string, idx int) string {
+	return parent + "[" + strconv.Itoa(idx) + "]"
+}
+
 func (s *Schema) initValidation() {
 	if s.Validations == nil {
 		s.Validations = &Validations{}
@@ -168,118 +557,194 @@ func (s *Schema) WithTagOptions(st reflect.StructTag) error {
 		if desc := st.Get(Description); desc != "" {
 			s.WithDescription(desc)
 		}
-		optStr := st.Get(Tag)
-		if optStr != "" {
-			opts, err := parseTag(optStr)
+		if optStr := st.Get(Tag); optStr != "" {
+			err := s.withTagOptions(optStr)
+			if err != nil {
+				return err
+			}
+		}
+		if optStr := st.Get(ValueTag); optStr != "" {
+			err := s.withValueTagOptions(optStr)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// withTagOptions sets the rules of the options of the "schema" tag
+func (s *Schema) withTagOptions(optStr string) error {
+	opts, err := parseTag(optStr)
+	if err != nil {
+		return &TagError{
+			Tag: Tag,
+			Err: err.Error(),
+		}
+	}
+	if opts.Contains(OptionsRequired) {
+		s.WithRequired(true)
+		if mode := opts.GetValue(OptionsRequired); mode != "" {
+			err = s.withRequiredMode(mode)
 			if err != nil {
 				return &TagError{
-					Tag: Tag,
+					Tag: Tag + "." + OptionsRequired,
 					Err: err.Error(),
 				}
 			}
-			if opts.Contains(OptionsRequired) {
-				s.WithRequired(true)
+		}
+	}
+	err = s.withConditionalTagOptions(opts)
+	if err != nil {
+		return err
+	}
+	s.withFieldTagOptions(opts)
+	s.withStrictTagOptions(opts)
+	err = s.withFileTagOptions(opts)
+	if err != nil {
+		return err
+	}
+	err = s.withMapTagOptions(opts)
+	if err != nil {
+		return err
+	}
+	if msg := opts.GetValue(Message); msg != "" {
+		err = s.withMessage(msg)
+		if err != nil {
+			return &TagError{
+				Tag: Tag + "." + Message,
+				Err: err.Error(),
 			}
-			if str := opts.GetValue(Enum); str != "" {
-				elements := strings.Split(str, ",")
-				err = s.withEnum(elements)
-				if err != nil {
-					return &TagError{
-						Tag: Tag + "." + Enum,
-						Err: err.Error(),
-					}
-				}
+		}
+	}
+	if str := opts.GetValue(Enum); str != "" {
+		elements := strings.Split(str, ",")
+		err = s.withEnum(elements)
+		if err != nil {
+			return &TagError{
+				Tag: Tag + "." + Enum,
+				Err: err.Error(),
 			}
-			var f64 float64
-			if minNum := opts.GetValue(MinNum); minNum != "" {
-				f64, err = strToFloat64(minNum)
-				if err != nil {
-					return &TagError{
-						Tag: Tag + "." + MinNum,
-						Err: err.Error(),
-					}
-				}
-				s.WithMinNum(f64)
+		}
+	}
+	if use := opts.GetValue(Use); use != "" {
+		err = s.withUse(strings.Split(use, ","))
+		if err != nil {
+			return &TagError{
+				Tag: Tag + "." + Use,
+				Err: err.Error(),
 			}
-			if maxNum := opts.GetValue(MaxNum); maxNum != "" {
-				f64, err = strToFloat64(maxNum)
-				if err != nil {
-					return &TagError{
-						Tag: Tag + "." + MaxNum,
-						Err: err.Error(),
-					}
-				}
-				s.WithMaxNum(f64)
+		}
+	}
+	var f64 float64
+	if minNum := opts.GetValue(MinNum); minNum != "" {
+		f64, err = strToFloat64(minNum)
+		if err != nil {
+			return &TagError{
+				Tag: Tag + "." + MinNum,
+				Err: err.Error(),
 			}
-			if minExcNum := opts.GetValue(MinExcNum); minExcNum != "" {
-				f64, err = strToFloat64(minExcNum)
-				if err != nil {
-					return &TagError{
-						Tag: Tag + "." + MinExcNum,
-						Err: err.Error(),
-					}
-				}
-				s.WithMinExcNum(f64)
+		}
+		s.WithMinNum(f64)
+	}
+	if maxNum := opts.GetValue(MaxNum); maxNum != "" {
+		f64, err = strToFloat64(maxNum)
+		if err != nil {
+			return &TagError{
+				Tag: Tag + "." + MaxNum,
+				Err: err.Error(),
 			}
-			if maxExcNum := opts.GetValue(MaxExcNum); maxExcNum != "" {
-				f64, err = strToFloat64(maxExcNum)
-				if err != nil {
-					return &TagError{
-						Tag: Tag + "." + MaxExcNum,
-						Err: err.Error(),
-					}
-				}
-				s.WithMaxExcNum(f64)
+		}
+		s.WithMaxNum(f64)
+	}
+	if minExcNum := opts.GetValue(MinExcNum); minExcNum != "" {
+		f64, err = strToFloat64(minExcNum)
+		if err != nil {
+			return &TagError{
+				Tag: Tag + "." + MinExcNum,
+				Err: err.Error(),
 			}
-			var i int
-			if minLen := opts.GetValue(MinLen); minLen != "" {
-				i, err = types.String(minLen).Int()
-				if err != nil {
-					return &TagError{
-						Tag: Tag + "." + MinLen,
-						Err: err.Error(),
-					}
-				}
-				s.WithMinLen(i)
+		}
+		s.WithMinExcNum(f64)
+	}
+	if maxExcNum := opts.GetValue(MaxExcNum); maxExcNum != "" {
+		f64, err = strToFloat64(maxExcNum)
+		if err != nil {
+			return &TagError{
+				Tag: Tag + "." + MaxExcNum,
+				Err: err.Error(),
 			}
-			if maxLen := opts.GetValue(MaxLen); maxLen != "" {
-				i, err = types.String(maxLen).Int()
-				if err != nil {
-					return &TagError{
-						Tag: Tag + "." + MaxLen,
-						Err: err.Error(),
-					}
-				}
-				s.WithMaxLen(i)
+		}
+		s.WithMaxExcNum(f64)
+	}
+	if opts.Contains(Integer) {
+		s.WithInteger(true)
+	}
+	var i int
+	if lenUnit := opts.GetValue(LenUnit); lenUnit != "" {
+		err = s.withLenUnit(lenUnit)
+		if err != nil {
+			return &TagError{
+				Tag: Tag + "." + LenUnit,
+				Err: err.Error(),
 			}
-			if minItems := opts.GetValue(MinItems); minItems != "" {
-				i, err = types.String(minItems).Int()
-				if err != nil {
-					return &TagError{
-						Tag: Tag + "." + MinItems,
-						Err: err.Error(),
-					}
-				}
-				s.WithMinItems(i)
+		}
+	}
+	if minLen := opts.GetValue(MinLen); minLen != "" {
+		i, err = types.String(minLen).Int()
+		if err != nil {
+			return &TagError{
+				Tag: Tag + "." + MinLen,
+				Err: err.Error(),
 			}
-			if maxItems := opts.GetValue(MaxItems); maxItems != "" {
-				i, err = types.String(maxItems).Int()
-				if err != nil {
-					return &TagError{
-						Tag: Tag + "." + MaxItems,
-						Err: err.Error(),
-					}
-				}
-				s.WithMaxItems(i)
+		}
+		s.WithMinLen(i)
+	}
+	if maxLen := opts.GetValue(MaxLen); maxLen != "" {
+		i, err = types.String(maxLen).Int()
+		if err != nil {
+			return &TagError{
+				Tag: Tag + "." + MaxLen,
+				Err: err.Error(),
 			}
-			if pattern := opts.GetValue(Pattern); pattern != "" {
-				err = s.withPattern(pattern)
-				if err != nil {
-					return &TagError{
-						Tag: Tag + "." + Pattern,
-						Err: err.Error(),
-					}
-				}
+		}
+		s.WithMaxLen(i)
+	}
+	if minItems := opts.GetValue(MinItems); minItems != "" {
+		i, err = types.String(minItems).Int()
+		if err != nil {
+			return &TagError{
+				Tag: Tag + "." + MinItems,
+				Err: err.Error(),
+			}
+		}
+		s.WithMinItems(i)
+	}
+	if maxItems := opts.GetValue(MaxItems); maxItems != "" {
+		i, err = types.String(maxItems).Int()
+		if err != nil {
+			return &TagError{
+				Tag: Tag + "." + MaxItems,
+				Err: err.Error(),
+			}
+		}
+		s.WithMaxItems(i)
+	}
+	if format := opts.GetValue(Format); format != "" {
+		err = s.withFormat(format)
+		if err != nil {
+			return &TagError{
+				Tag: Tag + "." + Format,
+				Err: err.Error(),
+			}
+		}
+	}
+	if pattern := opts.GetValue(Pattern); pattern != "" {
+		err = s.withPattern(pattern)
+		if err != nil {
+			return &TagError{
+				Tag: Tag + "." + Pattern,
+				Err: err.Error(),
 			}
 		}
 	}
@@ -301,6 +766,68 @@ func (s *Schema) WithRequired(required bool) *Schema {
 	return s
 }
 
+// WithRequiredMode sets the mode of the "required" rule, mode should be RequiredNonzero or
+// RequiredPresent, the properties of an object schema inherit the mode if they have no mode
+func (s *Schema) WithRequiredMode(mode string) *Schema {
+	err := s.withRequiredMode(mode)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func (s *Schema) withRequiredMode(mode string) error {
+	switch mode {
+	case RequiredNonzero, RequiredPresent:
+	default:
+		return fmt.Errorf("unknown required mode %q", mode)
+	}
+	s.initValidation()
+	s.Validations.RequiredMode = mode
+	return nil
+}
+
+// WithMessage sets the custom message of the failures, the message is a text/template which is rendered
+// with the MessageData
+func (s *Schema) WithMessage(msg string) *Schema {
+	err := s.withMessage(msg)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func (s *Schema) withMessage(msg string) error {
+	_, err := parseMessage(msg)
+	if err != nil {
+		return err
+	}
+	s.initValidation()
+	s.Validations.Message = msg
+	return nil
+}
+
+// WithUse adds the custom validators, which should be registered by RegisterValidator
+func (s *Schema) WithUse(names ...string) *Schema {
+	err := s.withUse(names)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func (s *Schema) withUse(names []string) error {
+	s.initValidation()
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if getValidator(name) == nil {
+			return fmt.Errorf("validator %q is not registered", name)
+		}
+		s.Validations.Use = append(s.Validations.Use, name)
+	}
+	return nil
+}
+
 var patterns = &matchers{}
 
 func (s *Schema) WithPattern(pattern string) *Schema {
@@ -321,6 +848,24 @@ func (s *Schema) withPattern(pattern string) error {
 	return nil
 }
 
+// WithFormat sets the format of strings, such as FormatEmail
+func (s *Schema) WithFormat(format string) *Schema {
+	err := s.withFormat(format)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func (s *Schema) withFormat(format string) error {
+	if _, ok := formatCheckers[format]; !ok {
+		return fmt.Errorf("unknown format %q", format)
+	}
+	s.initValidation()
+	s.Validations.Format = format
+	return nil
+}
+
 func (s *Schema) WithMaxNum(maxNum float64) *Schema {
 	s.initValidation()
 	s.Validations.MaxExcNum = nil
@@ -345,6 +890,13 @@ func (s *Schema) WithMinExcNum(minExcNum float64) *Schema {
 	s.Validations.MinExcNum = &minExcNum
 	return s
 }
+
+// WithInteger makes the numbers integers
+func (s *Schema) WithInteger(integer bool) *Schema {
+	s.initValidation()
+	s.Validations.Integer = integer
+	return s
+}
 func (s *Schema) WithMaxLen(maxLen int) *Schema {
 	s.initValidation()
 	s.Validations.MaxLen = &maxLen
@@ -355,6 +907,28 @@ func (s *Schema) WithMinLen(minLen int) *Schema {
 	s.Validations.MinLen = &minLen
 	return s
 }
+
+// WithLenUnit sets the unit of the string length, unit should be LenUnitByte, LenUnitRune or
+// LenUnitGrapheme, the properties of an object schema inherit the unit if they have no unit
+func (s *Schema) WithLenUnit(unit string) *Schema {
+	err := s.withLenUnit(unit)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func (s *Schema) withLenUnit(unit string) error {
+	switch unit {
+	case LenUnitByte, LenUnitRune, LenUnitGrapheme:
+	default:
+		return fmt.Errorf("unknown length unit %q", unit)
+	}
+	s.initValidation()
+	s.Validations.LenUnit = unit
+	return nil
+}
+
 func (s *Schema) WithMaxItems(maxItems int) *Schema {
 	s.initValidation()
 	s.Validations.MaxItems = &maxItems
@@ -198,3 +198,162 @@ func jsonStr(v interface{}) string {
 	}
 	return string(data)
 }
+
+func TestValidRef(t *testing.T) {
+	models := make(schema.Models)
+	s, err := models.NewSchema(&A{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ref := range []string{"github.com/orivil/schema_test.A", "github.com/orivil/schema_test.B", "github.com/orivil/schema_test.C"} {
+		if models.GetSchema(ref) == nil {
+			t.Fatalf("model %s is not registered", ref)
+		}
+	}
+	str := "1"
+	a := &A{
+		F1: "J",
+		B: &B{F11: &str, F12: &C{
+			F13: true,
+			F16: &A{F1: "Jay", B: &B{F11: &str, F12: &C{F13: true}}, F9: []int{1}},
+			F17: &B{F12: &C{}},
+		}},
+	}
+	infos, err := s.ValidAll(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// f12.f16 is a Ref of A, the nil Refs such as f12.f17.f12.f16 are validated as the zero values, an
+	// absent model is walked once on the path
+	need := []string{
+		"f12.f16.f12.f16.f01", "f12.f16.f12.f16.f11", "f12.f16.f12.f16.f12.f13", "f12.f16.f12.f16.f12.f17.f11",
+		"f12.f16.f12.f16.f12.f17.f12.f13", "f12.f16.f12.f16.f12.f17.f12.f17.f11", "f12.f16.f12.f17.f11",
+		"f12.f16.f12.f17.f12.f13", "f12.f16.f12.f17.f12.f16.f01", "f12.f16.f12.f17.f12.f16.f11",
+		"f12.f16.f12.f17.f12.f16.f12.f13", "f12.f16.f12.f17.f12.f16.f12.f17.f11", "f12.f16.f12.f17.f12.f17.f11",
+		"f12.f17.f11", "f12.f17.f12.f13", "f12.f17.f12.f16.f01", "f12.f17.f12.f16.f11", "f12.f17.f12.f16.f12.f13",
+		"f12.f17.f12.f16.f12.f17.f11", "f12.f17.f12.f16.f12.f17.f12.f13", "f12.f17.f12.f16.f12.f17.f12.f17.f11",
+		"f12.f17.f12.f17.f11", "f12.f17.f12.f17.f12.f13", "f12.f17.f12.f17.f12.f16.f01",
+		"f12.f17.f12.f17.f12.f16.f11", "f12.f17.f12.f17.f12.f16.f12.f13", "f12.f17.f12.f17.f12.f16.f12.f17.f11",
+		"f12.f17.f12.f17.f12.f17.f11",
+	}
+	if len(infos) != len(need) {
+		t.Fatalf("need failures of %v, got: %s", need, jsonStr(infos))
+	}
+	for i, info := range infos {
+		if info.Field != need[i] {
+			t.Errorf("need: %s, got: %s", need[i], info.Field)
+		}
+	}
+}
+
+type Address struct {
+	City string `json:"city" schema:"required;maxLen:2"`
+}
+
+type Person struct {
+	Home *Address `json:"home"`
+	Work *Address `json:"work" schema:"lenUnit:rune"`
+}
+
+func TestValidRefRules(t *testing.T) {
+	s, err := schema.NewSchema(&Person{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Property("work").Ref == "" {
+		t.Fatalf("need the Ref schema of work, got: %s", jsonStr(s))
+	}
+	// the nil Ref is validated the same as the nil inline object
+	infos, err := s.ValidAll(&Person{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 2 || infos[0].Field != "home.city" || infos[1].Field != "work.city" {
+		t.Fatalf("need required failures of home.city and work.city, got: %s", jsonStr(infos))
+	}
+	// the properties of the model inherit the lenUnit of the Ref
+	infos, err = s.ValidAll(&Person{Home: &Address{City: "éé"}, Work: &Address{City: "éé"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 || infos[0].Field != "home.city" || infos[0].MaxLen == nil {
+		t.Fatalf("need maxLen failure of home.city, got: %s", jsonStr(infos))
+	}
+}
+
+type decoderModel struct {
+	Name string `json:"name"`
+}
+
+func (d *decoderModel) Schema() *schema.Schema {
+	return &schema.Schema{Type: schema.Object, Properties: schema.Properties{
+		{Name: "name", Type: schema.String, Validations: &schema.Validations{Required: true}},
+	}}
+}
+
+func TestSchemaOf(t *testing.T) {
+	s1, err := schema.SchemaOf(&A{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2, err := schema.SchemaOf(&A{F1: "Jay"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s1 != s2 {
+		t.Fatal("need the cached schema")
+	}
+	s3, err := schema.NewSchema(&A{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jsonStr(s1) != jsonStr(s3) {
+		t.Fatalf("need: %s\ngot: %s", jsonStr(s3), jsonStr(s1))
+	}
+	// the recursive Ref schemas are resolved by the models of the cached schema
+	str := "1"
+	infos, err := s1.ValidAll(&A{F1: "J", B: &B{F11: &str, F12: &C{F13: true, F17: &B{F12: &C{}}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	need := []string{
+		"f12.f16.f01", "f12.f16.f11", "f12.f16.f12.f13", "f12.f16.f12.f17.f11", "f12.f16.f12.f17.f12.f13",
+		"f12.f16.f12.f17.f12.f17.f11", "f12.f17.f11", "f12.f17.f12.f13", "f12.f17.f12.f16.f01", "f12.f17.f12.f16.f11",
+		"f12.f17.f12.f16.f12.f13", "f12.f17.f12.f16.f12.f17.f11", "f12.f17.f12.f16.f12.f17.f12.f13",
+		"f12.f17.f12.f16.f12.f17.f12.f17.f11", "f12.f17.f12.f17.f11", "f12.f17.f12.f17.f12.f13",
+		"f12.f17.f12.f17.f12.f16.f01", "f12.f17.f12.f17.f12.f16.f11", "f12.f17.f12.f17.f12.f16.f12.f13",
+		"f12.f17.f12.f17.f12.f16.f12.f17.f11", "f12.f17.f12.f17.f12.f17.f11",
+	}
+	if len(infos) != len(need) {
+		t.Fatalf("need failures of %v, got: %s", need, jsonStr(infos))
+	}
+	for i, info := range infos {
+		if info.Field != need[i] {
+			t.Errorf("need: %s, got: %s", need[i], info.Field)
+		}
+	}
+
+	// the schemas depend on the values are not cached
+	m1, err := schema.SchemaOf(map[string]int{"a": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m2, err := schema.SchemaOf(map[string]int{"b": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m1 == m2 || m2.Property("b") == nil {
+		t.Fatalf("need the schema of the map value, got: %s", jsonStr(m2))
+	}
+	d1, err := schema.SchemaOf(&decoderModel{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d2, err := schema.SchemaOf(&decoderModel{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d1 == d2 || d1.Property("name") == nil {
+		t.Fatalf("need the schema of Decoder, got: %s", jsonStr(d1))
+	}
+}
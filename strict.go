@@ -0,0 +1,103 @@
+// Copyright 2020 orivil.com. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found at https://mit-license.org.
+
+package schema
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Strict makes every object reject the unknown properties, which are the keys of a map or the supplied
+// fields of a struct that are not the properties of the schema, the maps with the AdditionalProperties,
+// such as the maps built from the Go maps, are not affected since their other keys are validated by the
+// schema of the values. The supplied fields of a struct are known by the presence, such as the unknown
+// query params returned by UnmarshalUrlPresence, see WithPresence and Binder.Options
+func Strict() ValidOption {
+	return func(vr *validator) {
+		vr.strict = true
+	}
+}
+
+// WithStrict makes the object reject the unknown properties, the nested objects are not affected, see
+// Strict
+func (s *Schema) WithStrict(strict bool) *Schema {
+	s.initValidation()
+	s.Validations.Strict = strict
+	return s
+}
+
+func (s *Schema) withStrictTagOptions(opts tagOptions) {
+	if opts.Contains(OptionsStrict) {
+		s.WithStrict(true)
+	}
+}
+
+// isStrict reports whether the unknown properties of the object s are rejected
+func (vr *validator) isStrict(s *Schema) bool {
+	if s.AdditionalProperties != nil {
+		return false
+	}
+	return vr.strict || s.Validations != nil && s.Validations.Strict
+}
+
+// validUnknown fails the unknown properties of the map or the struct v of the strict object s
+func (s *Schema) validUnknown(vr *validator, field string, v reflect.Value) error {
+	if !vr.isStrict(s) {
+		return nil
+	}
+	// the failures have no description and message of the object
+	unknown := &Schema{}
+	if v.Kind() == reflect.Map {
+		keys, err := mapKeys(v)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if s.Property(key.name) == nil {
+				vr.fail(unknown, initFieldName(field, key.name), &Validations{Strict: true}, valueInterface(v.MapIndex(key.value)))
+				if vr.stopped() {
+					return nil
+				}
+			}
+		}
+		return nil
+	}
+	for _, name := range vr.unknownFields(s, field) {
+		vr.fail(unknown, initFieldName(field, name), &Validations{Strict: true}, nil)
+		if vr.stopped() {
+			return nil
+		}
+	}
+	return nil
+}
+
+// unknownFields returns the sorted names of the supplied fields of the object which are not the
+// properties of s, the names are the first segments of the presence paths under the field
+func (vr *validator) unknownFields(s *Schema, field string) []string {
+	prefix := ""
+	if field != "" {
+		prefix = field + "."
+	}
+	names := make(map[string]struct{})
+	for path := range vr.presence {
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		name := path[len(prefix):]
+		if idx := strings.IndexAny(name, ".["); idx != -1 {
+			name = name[:idx]
+		}
+		if name != "" && s.Property(name) == nil {
+			names[name] = struct{}{}
+		}
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
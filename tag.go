@@ -18,15 +18,64 @@ const (
 	MinNum      = "minNum"
 	MinExcNum   = "minExcNum"
 	MaxExcNum   = "maxExcNum"
+	Integer     = "integer"
 	MinLen      = "minLen"
 	MaxLen      = "maxLen"
 	MinItems    = "minItems"
 	MaxItems    = "maxItems"
 	Pattern     = "pattern"
+	Message     = "msg"
+	Use         = "use"
+	Format      = "format"
+	LenUnit     = "lenUnit"
+	Const       = "const"
+	MaxSize     = "maxSize"
+	MinSize     = "minSize"
+	Mime        = "mime"
+	Ext         = "ext"
+	MaxWidth    = "maxWidth"
+	MinWidth    = "minWidth"
+	MaxHeight   = "maxHeight"
+	MinHeight   = "minHeight"
+	EqField     = "eqField"
+	NeField     = "neField"
+	GtField     = "gtField"
+	GteField    = "gteField"
+	LtField     = "ltField"
+	LteField    = "lteField"
+	MinProps    = "minProps"
+	MaxProps    = "maxProps"
+	KeyPattern  = "keyPattern"
+	KeyMinLen   = "keyMinLen"
+	KeyMaxLen   = "keyMaxLen"
 )
 
 const (
 	OptionsRequired = "required"
+	RequiredIf      = "requiredIf"
+	RequiredWith    = "requiredWith"
+	RequiredWithout = "requiredWithout"
+	OptionsStrict   = "strict"
+)
+
+// the modes of the "required" rule, such as "required:present"
+const (
+	// RequiredNonzero means the value is not the zero value, it is the default mode
+	RequiredNonzero = "nonzero"
+	// RequiredPresent means the value is supplied, such as a non-nil pointer, a key of a map, a key of
+	// url values or JSON object
+	RequiredPresent = "present"
+)
+
+// the units of the string length, such as "lenUnit:rune"
+const (
+	// LenUnitByte counts the bytes of the UTF-8 encoding, it is the default unit
+	LenUnitByte = "byte"
+	// LenUnitRune counts the Unicode code points
+	LenUnitRune = "rune"
+	// LenUnitGrapheme counts the user-perceived characters, such as an emoji flag or a letter with
+	// combining marks
+	LenUnitGrapheme = "grapheme"
 )
 
 type TagError struct {
@@ -5,10 +5,14 @@
 package schema
 
 import (
+	"encoding"
 	"fmt"
 	"github.com/orivil/types"
+	"mime/multipart"
 	"net/url"
 	"reflect"
+	"strconv"
+	"sync"
 )
 
 type UrlUnmarshaler interface {
@@ -17,6 +21,13 @@ type UrlUnmarshaler interface {
 
 var urlUnmarshalerType = reflect.TypeOf(new(UrlUnmarshaler)).Elem()
 
+// UrlMarshaler overrides the encoding of MarshalUrl, it is the inverse of UrlUnmarshaler
+type UrlMarshaler interface {
+	MarshalUrl() (url.Values, error)
+}
+
+var urlMarshalerType = reflect.TypeOf(new(UrlMarshaler)).Elem()
+
 // UnmarshalUrl for un-marshaling values to v, v should be pointer to struct or it's reflect value
 func UnmarshalUrl(values url.Values, v interface{}) error {
 	var rv reflect.Value
@@ -25,101 +36,446 @@ func UnmarshalUrl(values url.Values, v interface{}) error {
 	} else {
 		rv = reflect.ValueOf(v)
 	}
-	return unmarshalUrl(values, &rv)
+	return unmarshalUrl(values, nil, &rv, "", nil)
+}
+
+// UnmarshalUrlPresence is the same as UnmarshalUrl but also returns the field paths of the supplied
+// values, which is used by the "required:present" rule and the Strict option, see WithPresence. The
+// paths of the unknown keys are also returned
+func UnmarshalUrlPresence(values url.Values, v interface{}) (Presence, error) {
+	var rv reflect.Value
+	if rev, ok := v.(reflect.Value); ok {
+		rv = rev
+	} else {
+		rv = reflect.ValueOf(v)
+	}
+	p := make(Presence)
+	err := unmarshalUrl(values, nil, &rv, "", p)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
 }
 
-func unmarshalUrl(values url.Values, rv *reflect.Value) error {
-	if rv.Type().Implements(urlUnmarshalerType) {
+// unmarshalUrl sets the fields of rv by the values, the File fields are set by the files if files is not nil
+func unmarshalUrl(values url.Values, files map[string][]*multipart.FileHeader, rv *reflect.Value, field string, p Presence) error {
+	plan := getUrlPlan(rv.Type())
+	if plan.unmarshaler {
 		return rv.Interface().(UrlUnmarshaler).UnmarshalUrl(values)
 	}
+	if plan.err != nil {
+		return plan.err
+	}
 	irv := reflect.Indirect(*rv)
-	ik := irv.Kind()
-	if ik == reflect.Struct {
-		numFields := irv.NumField()
-		irt := irv.Type()
-		for i := 0; i < numFields; i++ {
-			fv := irv.Field(i)
-			if fv.CanSet() {
-				ft := irt.Field(i)
-				if !isFieldIgnored(ft.Tag) {
-					if ft.Anonymous {
-						if ft.Type.Kind() == reflect.Ptr {
-							var setV reflect.Value
-							if fv.IsNil() {
-								nv := reflect.New(ft.Type.Elem())
-								fv.Set(nv)
-								setV = nv.Elem()
-							} else {
-								setV = fv.Elem()
-							}
-							err := unmarshalUrl(values, &setV)
-							if err != nil {
-								return err
-							}
-						}
-					} else {
-						property := getFieldName(ft.Tag)
-						if property == "" {
-							property = ft.Name
-						}
-						if vs := values[property]; len(vs) > 0 {
-							err := setUrlValue(vs, &fv)
-							if err != nil {
-								return err
-							}
-						}
+	if !irv.IsValid() {
+		return fmt.Errorf("only support struct or pointer of struct, got %s", irv.Kind())
+	}
+	if !irv.CanAddr() {
+		// the fields of a struct value are not settable
+		return nil
+	}
+	if p != nil {
+		// the unknown keys are also supplied, which are rejected by the Strict option
+		for key, vs := range values {
+			if len(vs) > 0 {
+				p.add(initFieldName(field, key))
+			}
+		}
+		for key, headers := range files {
+			if len(headers) > 0 {
+				p.add(initFieldName(field, key))
+			}
+		}
+	}
+	for _, f := range plan.fields {
+		fv := irv.Field(f.index)
+		if f.embedded {
+			if fv.IsNil() {
+				fv.Set(reflect.New(fv.Type().Elem()))
+			}
+			setV := fv.Elem()
+			err := unmarshalUrl(values, files, &setV, field, p)
+			if err != nil {
+				return err
+			}
+		} else if headers := files[f.property]; len(headers) > 0 && f.file {
+			if p != nil {
+				p.add(initFieldName(field, f.property))
+			}
+			err := setFileValue(headers, &fv)
+			if err != nil {
+				return err
+			}
+		} else if vs := values[f.property]; len(vs) > 0 {
+			name := initFieldName(field, f.property)
+			if p != nil {
+				p.add(name)
+			}
+			err := f.set(vs, fv, name, p)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// urlPlan is the compiled fields of a type for unmarshalUrl, it is built once for every type
+type urlPlan struct {
+	unmarshaler bool
+	marshaler   bool
+	// err is not nil if the type is not a struct or pointer of struct
+	err    error
+	fields []*urlField
+}
+
+type urlField struct {
+	index    int
+	property string
+	// embedded is true for the anonymous pointer of struct, whose fields are promoted
+	embedded bool
+	// file is true if the field could be set by the files
+	file bool
+	set  urlSetter
+}
+
+// urlSetter sets v by the values, v is a settable field
+type urlSetter func(values []string, v reflect.Value, field string, p Presence) error
+
+var urlPlans, urlSetters sync.Map
+
+func getUrlPlan(t reflect.Type) *urlPlan {
+	if plan, ok := urlPlans.Load(t); ok {
+		return plan.(*urlPlan)
+	}
+	plan := &urlPlan{unmarshaler: t.Implements(urlUnmarshalerType), marshaler: t.Implements(urlMarshalerType)}
+	it := t
+	if it.Kind() == reflect.Ptr {
+		it = it.Elem()
+	}
+	if it.Kind() != reflect.Struct {
+		plan.err = fmt.Errorf("only support struct or pointer of struct, got %s", it.Kind())
+	} else {
+		for i := 0; i < it.NumField(); i++ {
+			ft := it.Field(i)
+			// the unexported fields are not settable
+			if ft.PkgPath != "" || isFieldIgnored(ft.Tag) {
+				continue
+			}
+			if ft.Anonymous {
+				if ft.Type.Kind() == reflect.Ptr {
+					plan.fields = append(plan.fields, &urlField{index: i, embedded: true})
+				}
+				continue
+			}
+			property := getFieldName(ft.Tag)
+			if property == "" {
+				property = ft.Name
+			}
+			plan.fields = append(plan.fields, &urlField{
+				index:    i,
+				property: property,
+				file:     isFileType(ft.Type),
+				set:      getUrlSetter(ft.Type),
+			})
+		}
+	}
+	actual, _ := urlPlans.LoadOrStore(t, plan)
+	return actual.(*urlPlan)
+}
+
+// getUrlSetter returns the setter of the type, the setters are compiled lazily so the recursive types
+// are supported
+func getUrlSetter(t reflect.Type) urlSetter {
+	if set, ok := urlSetters.Load(t); ok {
+		return set.(urlSetter)
+	}
+	set := compileUrlSetter(t)
+	actual, _ := urlSetters.LoadOrStore(t, set)
+	return actual.(urlSetter)
+}
+
+func compileUrlSetter(t reflect.Type) urlSetter {
+	it := t
+	if it.Kind() == reflect.Ptr {
+		it = it.Elem()
+	}
+	if isTextUnmarshaler(it) {
+		return ptrUrlSetter(t, it, setText)
+	}
+	var set urlSetter
+	switch ik := it.Kind(); ik {
+	case reflect.Struct:
+		// the pointer is passed to unmarshalUrl for the UrlUnmarshaler
+		return func(values []string, v reflect.Value, field string, p Presence) error {
+			if v.Kind() == reflect.Ptr && v.IsNil() {
+				v.Set(reflect.New(it))
+			}
+			urlValues, err := url.ParseQuery(values[0])
+			if err != nil {
+				return err
+			}
+			return unmarshalUrl(urlValues, nil, &v, field, p)
+		}
+	case reflect.Slice:
+		if isTextUnmarshaler(indirectType(it.Elem())) {
+			// the items such as the times are set one by one
+			setItem := getUrlSetter(it.Elem())
+			set = func(values []string, v reflect.Value, field string, p Presence) error {
+				items := reflect.MakeSlice(it, len(values), len(values))
+				for i, value := range values {
+					err := setItem([]string{value}, items.Index(i), field, p)
+					if err != nil {
+						return err
 					}
 				}
+				v.Set(items)
+				return nil
 			}
+			break
+		}
+		ek := it.Elem().Kind()
+		set = func(values []string, v reflect.Value, field string, p Presence) error {
+			vs := make([]interface{}, len(values))
+			for i, value := range values {
+				vs[i] = value
+			}
+			i, err := types.ToSlice(ek, vs)
+			if err != nil {
+				return err
+			}
+			v.Set(reflect.ValueOf(i))
+			return nil
+		}
+	case reflect.Map:
+		// the map is decoded from an embedded query string the same as a nested struct
+		setItem := getUrlSetter(it.Elem())
+		set = func(values []string, v reflect.Value, field string, p Presence) error {
+			if it.Key().Kind() != reflect.String {
+				return fmt.Errorf("only support map of string keys, got %s", it.Key().Kind())
+			}
+			urlValues, err := url.ParseQuery(values[0])
+			if err != nil {
+				return err
+			}
+			m := reflect.MakeMapWithSize(it, len(urlValues))
+			for key, vs := range urlValues {
+				name := initFieldName(field, key)
+				if p != nil {
+					p.add(name)
+				}
+				ev := reflect.New(it.Elem()).Elem()
+				err = setItem(vs, ev, name, p)
+				if err != nil {
+					return err
+				}
+				m.SetMapIndex(reflect.ValueOf(key).Convert(it.Key()), ev)
+			}
+			v.Set(m)
+			return nil
+		}
+	case reflect.String:
+		set = func(values []string, v reflect.Value, field string, p Presence) error {
+			v.SetString(values[0])
+			return nil
+		}
+	default:
+		set = func(values []string, v reflect.Value, field string, p Presence) error {
+			i, err := types.ToValue(ik, values[0])
+			if err != nil {
+				return err
+			}
+			v.Set(reflect.ValueOf(i))
+			return nil
 		}
-	} else {
-		return fmt.Errorf("only support struct or pointer of struct, got %s", ik)
 	}
-	return nil
+	return ptrUrlSetter(t, it, set)
 }
 
-func setUrlValue(values []string, vp *reflect.Value) error {
-	var v = *vp
-	if v.Kind() == reflect.Ptr {
+// ptrUrlSetter returns the setter of t which allocates the nil pointer of it before calling set
+func ptrUrlSetter(t, it reflect.Type, set urlSetter) urlSetter {
+	if t.Kind() != reflect.Ptr {
+		return set
+	}
+	return func(values []string, v reflect.Value, field string, p Presence) error {
 		if v.IsNil() {
-			nv := reflect.New(v.Type().Elem())
-			v.Set(nv)
-			v = nv.Elem()
-		} else {
-			v = v.Elem()
+			v.Set(reflect.New(it))
 		}
+		return set(values, v.Elem(), field, p)
+	}
+}
+
+var textMarshalerType = reflect.TypeOf(new(encoding.TextMarshaler)).Elem()
+
+// isTextUnmarshaler reports whether the values of t are decoded by encoding.TextUnmarshaler, such as
+// time.Time which is decoded from an RFC 3339 string, the UrlUnmarshaler is preferred
+func isTextUnmarshaler(t reflect.Type) bool {
+	pt := reflect.PtrTo(t)
+	return pt.Implements(textUnmarshalerType) && !pt.Implements(urlUnmarshalerType)
+}
+
+// textMarshaler returns the encoding.TextMarshaler of v, it is the inverse of isTextUnmarshaler
+func textMarshaler(v reflect.Value) (encoding.TextMarshaler, bool) {
+	if v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface || !v.CanInterface() {
+		return nil, false
+	}
+	t := v.Type()
+	if t.Implements(urlMarshalerType) || reflect.PtrTo(t).Implements(urlMarshalerType) {
+		return nil, false
 	}
-	it := v.Type()
-	ik := it.Kind()
-	if ik == reflect.Slice {
-		vs := make([]interface{}, len(values))
-		for i, value := range values {
-			vs[i] = value
+	if t.Implements(textMarshalerType) {
+		return v.Interface().(encoding.TextMarshaler), true
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textMarshalerType) {
+		return v.Addr().Interface().(encoding.TextMarshaler), true
+	}
+	return nil, false
+}
+
+// setText sets v by encoding.TextUnmarshaler, v is addressable
+func setText(values []string, v reflect.Value, field string, p Presence) error {
+	return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(values[0]))
+}
+
+// MarshalUrl encodes v to url values, it is the inverse of UnmarshalUrl. The nil pointers, the nil maps
+// and the File fields are omitted, the slices are encoded as repeated keys, the nested structs and the
+// maps are encoded as url encoded values and the encoding.TextMarshaler values such as time.Time are
+// encoded as the texts. v should be struct, pointer to struct or it's reflect value
+func MarshalUrl(v interface{}) (url.Values, error) {
+	var rv reflect.Value
+	if rev, ok := v.(reflect.Value); ok {
+		rv = rev
+	} else {
+		rv = reflect.ValueOf(v)
+	}
+	if !rv.IsValid() {
+		return nil, fmt.Errorf("only support struct or pointer of struct, got %s", rv.Kind())
+	}
+	values := make(url.Values)
+	err := marshalUrl(values, rv)
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func marshalUrl(values url.Values, rv reflect.Value) error {
+	if rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil
+	}
+	plan := getUrlPlan(rv.Type())
+	if !plan.marshaler && rv.Kind() != reflect.Ptr && rv.CanAddr() {
+		if addr := rv.Addr(); getUrlPlan(addr.Type()).marshaler {
+			rv, plan = addr, getUrlPlan(addr.Type())
 		}
-		i, err := types.ToSlice(it.Elem().Kind(), vs)
+	}
+	if plan.marshaler {
+		vs, err := rv.Interface().(UrlMarshaler).MarshalUrl()
 		if err != nil {
 			return err
 		}
-		v.Set(reflect.ValueOf(i))
-	} else if ik == reflect.Struct {
-		urlValues, err := url.ParseQuery(values[0])
+		for key, v := range vs {
+			values[key] = append(values[key], v...)
+		}
+		return nil
+	}
+	if plan.err != nil {
+		return plan.err
+	}
+	irv := reflect.Indirect(rv)
+	for _, f := range plan.fields {
+		fv := irv.Field(f.index)
+		if f.embedded {
+			err := marshalUrl(values, fv)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		}
+		if GoToJSONType(fv.Type()) == File || fv.Kind() == reflect.Map && fv.IsNil() {
+			continue
+		}
+		if fv.Kind() == reflect.Slice && !isTextUnmarshaler(fv.Type()) {
+			for i := 0; i < fv.Len(); i++ {
+				str, err := formatUrlValue(fv.Index(i))
+				if err != nil {
+					return fmt.Errorf("field %s: %v", f.property, err)
+				}
+				values.Add(f.property, str)
+			}
+			continue
+		}
+		str, err := formatUrlValue(fv)
 		if err != nil {
-			return err
+			return fmt.Errorf("field %s: %v", f.property, err)
 		}
-		return unmarshalUrl(urlValues, vp)
-	} else {
-		i, err := types.ToValue(ik, values[0])
+		values.Set(f.property, str)
+	}
+	return nil
+}
+
+// formatUrlValue encodes the value which could be decoded by the url setters
+func formatUrlValue(v reflect.Value) (string, error) {
+	if m, ok := textMarshaler(v); ok {
+		text, err := m.MarshalText()
+		return string(text), err
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), nil
+	case reflect.Ptr:
+		if v.IsNil() {
+			return "", fmt.Errorf("nil pointer of %s", v.Type())
+		}
+		return formatUrlValue(v.Elem())
+	case reflect.Struct:
+		nested := make(url.Values)
+		err := marshalUrl(nested, v)
 		if err != nil {
-			return err
+			return "", err
 		}
-		var nv reflect.Value
-		if v.Kind() == reflect.Ptr {
-			nv = reflect.New(it)
-			nv.Elem().Set(reflect.ValueOf(i))
-		} else {
-			nv = reflect.ValueOf(i)
+		return nested.Encode(), nil
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return "", fmt.Errorf("only support map of string keys, got %s", v.Type().Key().Kind())
 		}
-		v.Set(nv)
+		nested := make(url.Values)
+		for _, key := range v.MapKeys() {
+			item := reflect.Indirect(v.MapIndex(key))
+			if item.Kind() == reflect.Slice && !isTextUnmarshaler(item.Type()) {
+				for i := 0; i < item.Len(); i++ {
+					str, err := formatUrlValue(item.Index(i))
+					if err != nil {
+						return "", err
+					}
+					nested.Add(key.String(), str)
+				}
+				continue
+			}
+			str, err := formatUrlValue(item)
+			if err != nil {
+				return "", err
+			}
+			nested.Set(key.String(), str)
+		}
+		return nested.Encode(), nil
+	default:
+		return "", fmt.Errorf("unsupported kind %s", v.Kind())
 	}
-	return nil
 }
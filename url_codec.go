@@ -0,0 +1,415 @@
+// Copyright 2020 orivil.com. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found at https://mit-license.org.
+
+package schema
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// the syntaxes of the nested keys of url values
+const (
+	// NestedQuery encodes a nested struct as an embedded query string, such as "user=name%3Dx", it is
+	// the syntax of UnmarshalUrl and MarshalUrl
+	NestedQuery = "query"
+	// NestedBracket is the syntax of PHP and most frontend libraries, such as "user[name]=x",
+	// "user[tags][]=a" and "items[0][id]=1"
+	NestedBracket = "bracket"
+	// NestedDot is the syntax such as "user.name=x", "user.tags=a" and "items[0].id=1"
+	NestedDot = "dot"
+)
+
+// UrlCodec encodes and decodes the url values of the nested structs, slices and maps by the syntax of
+// the nested keys. The items of a slice are ordered by the indexes and the missing indexes are skipped,
+// such as "items[0]" and "items[5]" are decoded as two items
+type UrlCodec struct {
+	Syntax string
+}
+
+var (
+	BracketCodec = &UrlCodec{Syntax: NestedBracket}
+	DotCodec     = &UrlCodec{Syntax: NestedDot}
+)
+
+// urlNode is a key of the nested keys, such as "user" of "user[name]"
+type urlNode struct {
+	values   []string
+	children map[string]*urlNode
+}
+
+func (n *urlNode) child(key string) *urlNode {
+	if n.children == nil {
+		n.children = make(map[string]*urlNode)
+	}
+	c := n.children[key]
+	if c == nil {
+		c = &urlNode{}
+		n.children[key] = c
+	}
+	return c
+}
+
+// Unmarshal is the same as UnmarshalUrl but decodes the nested keys by the syntax
+func (c *UrlCodec) Unmarshal(values url.Values, v interface{}) error {
+	_, err := c.unmarshal(values, v, nil)
+	return err
+}
+
+// UnmarshalPresence is the same as UnmarshalUrlPresence but decodes the nested keys by the syntax
+func (c *UrlCodec) UnmarshalPresence(values url.Values, v interface{}) (Presence, error) {
+	return c.unmarshal(values, v, make(Presence))
+}
+
+func (c *UrlCodec) unmarshal(values url.Values, v interface{}, p Presence) (Presence, error) {
+	var rv reflect.Value
+	if rev, ok := v.(reflect.Value); ok {
+		rv = rev
+	} else {
+		rv = reflect.ValueOf(v)
+	}
+	switch c.Syntax {
+	case NestedQuery, "":
+		return p, unmarshalUrl(values, nil, &rv, "", p)
+	case NestedBracket, NestedDot:
+	default:
+		return nil, fmt.Errorf("unknown nested syntax %s", c.Syntax)
+	}
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("only support pointer of struct, got %s", rv.Kind())
+	}
+	if getUrlPlan(rv.Type()).unmarshaler {
+		return p, rv.Interface().(UrlUnmarshaler).UnmarshalUrl(values)
+	}
+	root := &urlNode{}
+	for key, vs := range values {
+		node := root
+		for _, seg := range c.splitKey(key) {
+			// the empty segment such as "tags[]" appends the values
+			if seg != "" {
+				node = node.child(seg)
+			}
+		}
+		node.values = append(node.values, vs...)
+	}
+	err := c.decodeStruct(root, rv.Elem(), "", p)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// splitKey splits the key to the segments, such as "items[0][id]" to "items", "0" and "id"
+func (c *UrlCodec) splitKey(key string) []string {
+	if c.Syntax != NestedDot {
+		return splitBrackets(key)
+	}
+	var segs []string
+	for _, part := range strings.Split(key, ".") {
+		segs = append(segs, splitBrackets(part)...)
+	}
+	return segs
+}
+
+func splitBrackets(key string) []string {
+	idx := strings.IndexByte(key, '[')
+	if idx <= 0 || !strings.HasSuffix(key, "]") {
+		return []string{key}
+	}
+	segs := []string{key[:idx]}
+	for rest := key[idx:]; rest != ""; {
+		end := strings.IndexByte(rest, ']')
+		if rest[0] != '[' || end == -1 {
+			// not a nested key
+			return []string{key}
+		}
+		segs = append(segs, rest[1:end])
+		rest = rest[end+1:]
+	}
+	return segs
+}
+
+func (c *UrlCodec) decodeStruct(node *urlNode, v reflect.Value, field string, p Presence) error {
+	if p != nil {
+		// every key is supplied as unmarshalUrl does
+		for key := range node.children {
+			p.add(initFieldName(field, key))
+		}
+	}
+	for _, f := range getUrlPlan(v.Type()).fields {
+		fv := v.Field(f.index)
+		if f.embedded {
+			if fv.IsNil() {
+				fv.Set(reflect.New(fv.Type().Elem()))
+			}
+			err := c.decodeStruct(node, fv.Elem(), field, p)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		child := node.children[f.property]
+		if child == nil {
+			continue
+		}
+		name := initFieldName(field, f.property)
+		if p != nil {
+			p.add(name)
+		}
+		err := c.decode(child, fv, name, p)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decode sets the settable v by the node
+func (c *UrlCodec) decode(node *urlNode, v reflect.Value, field string, p Presence) error {
+	t := v.Type()
+	if t.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(t.Elem()))
+		}
+		return c.decode(node, v.Elem(), field, p)
+	}
+	if len(node.children) > 0 && reflect.PtrTo(t).Implements(urlUnmarshalerType) {
+		values := make(url.Values)
+		c.flatten(node, "", values)
+		return v.Addr().Interface().(UrlUnmarshaler).UnmarshalUrl(values)
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if len(node.children) == 0 {
+			// the embedded query string is still supported
+			if len(node.values) > 0 {
+				return getUrlSetter(t)(node.values, v, field, p)
+			}
+			return nil
+		}
+		return c.decodeStruct(node, v, field, p)
+	case reflect.Slice:
+		indexes, err := sortIndexes(node)
+		if err != nil {
+			return fmt.Errorf("field %s: %v", field, err)
+		}
+		ek := indirectType(t.Elem()).Kind()
+		if ek == reflect.Struct || ek == reflect.Map || ek == reflect.Slice {
+			items := reflect.MakeSlice(t, len(indexes), len(indexes))
+			for i, idx := range indexes {
+				name := initItemName(field, i)
+				if p != nil {
+					p.add(name)
+				}
+				err = c.decode(node.children[idx], items.Index(i), name, p)
+				if err != nil {
+					return err
+				}
+			}
+			v.Set(items)
+			return nil
+		}
+		strs := node.values
+		for _, idx := range indexes {
+			strs = append(strs, node.children[idx].values...)
+		}
+		if len(strs) == 0 {
+			return nil
+		}
+		return getUrlSetter(t)(strs, v, field, p)
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return fmt.Errorf("field %s: only support map of string keys, got %s", field, t.Key().Kind())
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(t))
+		}
+		for key, child := range node.children {
+			name := initFieldName(field, key)
+			if p != nil {
+				p.add(name)
+			}
+			ev := reflect.New(t.Elem()).Elem()
+			err := c.decode(child, ev, name, p)
+			if err != nil {
+				return err
+			}
+			v.SetMapIndex(reflect.ValueOf(key).Convert(t.Key()), ev)
+		}
+		return nil
+	default:
+		if len(node.values) == 0 {
+			return nil
+		}
+		return getUrlSetter(t)(node.values, v, field, p)
+	}
+}
+
+// sortIndexes returns the keys of the children which should be the indexes of slice items
+func sortIndexes(node *urlNode) ([]string, error) {
+	indexes := make([]string, 0, len(node.children))
+	nums := make(map[string]int, len(node.children))
+	for key := range node.children {
+		num, err := strconv.Atoi(key)
+		if err != nil || num < 0 {
+			return nil, fmt.Errorf("invalid index %q", key)
+		}
+		indexes = append(indexes, key)
+		nums[key] = num
+	}
+	sort.Slice(indexes, func(i, j int) bool {
+		return nums[indexes[i]] < nums[indexes[j]]
+	})
+	return indexes, nil
+}
+
+// flatten converts the node to the url values for UrlUnmarshaler, the keys are relative to the node
+func (c *UrlCodec) flatten(node *urlNode, prefix string, values url.Values) {
+	if prefix != "" && len(node.values) > 0 {
+		values[prefix] = append(values[prefix], node.values...)
+	}
+	for key, child := range node.children {
+		if _, err := strconv.Atoi(key); err == nil && prefix != "" {
+			c.flatten(child, prefix+"["+key+"]", values)
+		} else {
+			c.flatten(child, c.joinKey(prefix, key), values)
+		}
+	}
+}
+
+// joinKey returns the nested key of the parent key, the key could be a nested key such as "a[b]"
+func (c *UrlCodec) joinKey(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	if c.Syntax == NestedDot {
+		return parent + "." + key
+	}
+	if idx := strings.IndexByte(key, '['); idx > 0 {
+		return parent + "[" + key[:idx] + "]" + key[idx:]
+	}
+	return parent + "[" + key + "]"
+}
+
+// Marshal is the same as MarshalUrl but encodes the nested structs, slices and maps by the syntax
+func (c *UrlCodec) Marshal(v interface{}) (url.Values, error) {
+	switch c.Syntax {
+	case NestedQuery, "":
+		return MarshalUrl(v)
+	case NestedBracket, NestedDot:
+	default:
+		return nil, fmt.Errorf("unknown nested syntax %s", c.Syntax)
+	}
+	var rv reflect.Value
+	if rev, ok := v.(reflect.Value); ok {
+		rv = rev
+	} else {
+		rv = reflect.ValueOf(v)
+	}
+	if reflect.Indirect(rv).Kind() != reflect.Struct {
+		return nil, fmt.Errorf("only support struct or pointer of struct, got %s", rv.Kind())
+	}
+	values := make(url.Values)
+	err := c.encode(values, "", rv)
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func (c *UrlCodec) encode(values url.Values, key string, v reflect.Value) error {
+	if isNilValue(v) {
+		return nil
+	}
+	if v.Type().Implements(urlMarshalerType) || v.CanAddr() && v.Addr().Type().Implements(urlMarshalerType) {
+		if !v.Type().Implements(urlMarshalerType) {
+			v = v.Addr()
+		}
+		vs, err := v.Interface().(UrlMarshaler).MarshalUrl()
+		if err != nil {
+			return err
+		}
+		for k, strs := range vs {
+			nk := c.joinKey(key, k)
+			values[nk] = append(values[nk], strs...)
+		}
+		return nil
+	}
+	if GoToJSONType(v.Type()) == File {
+		return nil
+	}
+	if _, ok := textMarshaler(v); ok {
+		// the values such as the times are encoded as the texts
+		str, err := formatUrlValue(v)
+		if err != nil {
+			return fmt.Errorf("field %s: %v", key, err)
+		}
+		values.Add(key, str)
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return c.encode(values, key, v.Elem())
+	case reflect.Struct:
+		for _, f := range getUrlPlan(v.Type()).fields {
+			fv := v.Field(f.index)
+			if f.embedded {
+				err := c.encode(values, key, fv)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			err := c.encode(values, c.joinKey(key, f.property), fv)
+			if err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		ek := indirectType(v.Type().Elem()).Kind()
+		for i := 0; i < v.Len(); i++ {
+			item := v.Index(i)
+			var err error
+			if ek == reflect.Struct || ek == reflect.Map || ek == reflect.Slice {
+				err = c.encode(values, key+"["+strconv.Itoa(i)+"]", item)
+			} else if !isNilValue(item) {
+				var str string
+				str, err = formatUrlValue(item)
+				if c.Syntax == NestedBracket {
+					values.Add(key+"[]", str)
+				} else {
+					values.Add(key, str)
+				}
+			}
+			if err != nil {
+				return fmt.Errorf("field %s: %v", key, err)
+			}
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("field %s: only support map of string keys, got %s", key, v.Type().Key().Kind())
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].String() < keys[j].String()
+		})
+		for _, k := range keys {
+			err := c.encode(values, c.joinKey(key, k.String()), v.MapIndex(k))
+			if err != nil {
+				return err
+			}
+		}
+	default:
+		str, err := formatUrlValue(v)
+		if err != nil {
+			return fmt.Errorf("field %s: %v", key, err)
+		}
+		values.Add(key, str)
+	}
+	return nil
+}
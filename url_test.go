@@ -5,9 +5,11 @@
 package schema_test
 
 import (
+	"fmt"
 	"github.com/orivil/schema"
 	"net/url"
 	"testing"
+	"time"
 )
 
 type Anonymous struct {
@@ -51,7 +53,7 @@ func TestUnmarshalUrlValues(t *testing.T) {
 	}
 }
 
-// 973 ns/op
+// 391 ns/op
 func BenchmarkUnmarshalUrlValues(b *testing.B) {
 	type Params struct {
 		Str string `json:"str"`
@@ -70,5 +72,247 @@ func BenchmarkUnmarshalUrlValues(b *testing.B) {
 	}
 }
 
+// 5162 ns/op
+func BenchmarkUnmarshalUrlNested(b *testing.B) {
+	values := url.Values{
+		"str":       []string{"Nina"},
+		"int":       []string{"64"},
+		"int_8":     []string{"8"},
+		"slice_int": []string{"1", "2"},
+		"slice_str": []string{"1", "2"},
+		"bool":      []string{"true"},
+		"params":    []string{"str=Jay&int=32"},
+	}
+	for i := 0; i < b.N; i++ {
+		params := &Params{}
+		err := schema.UnmarshalUrl(values, params)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// 11387 ns/op
+func BenchmarkNewSchema(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, err := schema.NewSchema(&Params{})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// 110 ns/op
+func BenchmarkSchemaOf(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, err := schema.SchemaOf(&Params{})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// 59 ns/op
+func BenchmarkSchemaOfParallel(b *testing.B) {
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_, err := schema.SchemaOf(&Params{})
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
 func newInt(i int) *int    { return &i }
 func newBool(b bool) *bool { return &b }
+
+type point struct {
+	X, Y int
+}
+
+func (p *point) UnmarshalUrl(vs url.Values) error {
+	_, err := fmt.Sscanf(vs.Get("xy"), "%d,%d", &p.X, &p.Y)
+	return err
+}
+
+func (p point) MarshalUrl() (url.Values, error) {
+	return url.Values{"xy": {fmt.Sprintf("%d,%d", p.X, p.Y)}}, nil
+}
+
+func TestMarshalUrl(t *testing.T) {
+	type query struct {
+		*Params
+		Page   int      `json:"page"`
+		Size   *uint8   `json:"size"`
+		Rate   float64  `json:"rate"`
+		Ignore string   `json:"-"`
+		Point  *point   `json:"point"`
+		Nested *Params  `json:"nested"`
+		Flags  []bool   `json:"flags"`
+		Empty  []string `json:"empty"`
+	}
+	size := uint8(20)
+	q := &query{
+		Params: &Params{
+			Str:       "Nina",
+			Int:       newInt(64),
+			SliceInt:  []int{1, 2},
+			Anonymous: &Anonymous{Bool: newBool(true)},
+		},
+		Page:   2,
+		Size:   &size,
+		Rate:   0.5,
+		Ignore: "ignore",
+		Point:  &point{X: 1, Y: 2},
+		Nested: &Params{Str: "Jay", SliceStr: []string{"a", "b"}},
+		Flags:  []bool{true, false},
+	}
+	values, err := schema.MarshalUrl(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	need := "bool=true&flags=true&flags=false&int=64&int_8=0&nested=int_8%3D0%26slice_str%3Da%26slice_str%3Db%26str%3DJay" +
+		"&page=2&point=xy%3D1%252C2&rate=0.5&size=20&slice_int=1&slice_int=2&str=Nina"
+	if got := values.Encode(); got != need {
+		t.Fatalf("need: %s\ngot: %s", need, got)
+	}
+	got := &query{}
+	err = schema.UnmarshalUrl(values, got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.Ignore = ""
+	// the anonymous pointers are always allocated by UnmarshalUrl
+	q.Nested.Anonymous = &Anonymous{}
+	if jsonStr(got) != jsonStr(q) {
+		t.Fatalf("need: %s\ngot: %s", jsonStr(q), jsonStr(got))
+	}
+}
+
+func TestMarshalUrlText(t *testing.T) {
+	// the times are encoded as the RFC 3339 strings and the maps as the embedded query strings
+	type event struct {
+		Start  time.Time         `json:"start"`
+		End    *time.Time        `json:"end"`
+		Days   []time.Time       `json:"days"`
+		Meta   map[string]string `json:"meta"`
+		Counts map[string][]int  `json:"counts"`
+	}
+	start := time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC)
+	end := start.Add(time.Hour)
+	need := &event{
+		Start:  start,
+		End:    &end,
+		Days:   []time.Time{start, end},
+		Meta:   map[string]string{"k1": "v1", "k2": "v2"},
+		Counts: map[string][]int{"a": {1, 2}},
+	}
+	values, err := schema.MarshalUrl(need)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := values.Get("start"); got != "2020-01-02T15:04:05Z" {
+		t.Fatalf("need RFC 3339 start, got: %s", got)
+	}
+	got := &event{}
+	err = schema.UnmarshalUrl(values, got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jsonStr(got) != jsonStr(need) {
+		t.Fatalf("need: %s\ngot: %s", jsonStr(need), jsonStr(got))
+	}
+	for _, codec := range []*schema.UrlCodec{schema.BracketCodec, schema.DotCodec} {
+		values, err = codec.Marshal(need)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = &event{}
+		err = codec.Unmarshal(values, got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if jsonStr(got) != jsonStr(need) {
+			t.Fatalf("%s need: %s\ngot: %s", codec.Syntax, jsonStr(need), jsonStr(got))
+		}
+	}
+}
+
+func TestUrlCodec(t *testing.T) {
+	type item struct {
+		ID   int      `json:"id"`
+		Tags []string `json:"tags"`
+	}
+	type form struct {
+		Name   string            `json:"name"`
+		User   *Params           `json:"user"`
+		Items  []*item           `json:"items"`
+		Meta   map[string]string `json:"meta"`
+		Groups map[string]item   `json:"groups"`
+		Point  point             `json:"point"`
+	}
+	need := &form{
+		Name:   "Nina",
+		User:   &Params{Str: "Jay", SliceStr: []string{"a", "b"}, Anonymous: &Anonymous{}},
+		Items:  []*item{{ID: 1, Tags: []string{"x"}}, {ID: 2}},
+		Meta:   map[string]string{"k1": "v1", "k2": "v2"},
+		Groups: map[string]item{"g": {ID: 3}},
+		Point:  point{X: 1, Y: 2},
+	}
+	cases := []struct {
+		codec  *schema.UrlCodec
+		encode string
+	}{
+		{
+			codec: schema.BracketCodec,
+			encode: "groups[g][id]=3&items[0][id]=1&items[0][tags][]=x&items[1][id]=2&meta[k1]=v1&meta[k2]=v2&name=Nina" +
+				"&point[xy]=1,2&user[int_8]=0&user[slice_str][]=a&user[slice_str][]=b&user[str]=Jay",
+		},
+		{
+			codec: schema.DotCodec,
+			encode: "groups.g.id=3&items[0].id=1&items[0].tags=x&items[1].id=2&meta.k1=v1&meta.k2=v2&name=Nina" +
+				"&point.xy=1,2&user.int_8=0&user.slice_str=a&user.slice_str=b&user.str=Jay",
+		},
+	}
+	for _, c := range cases {
+		values, err := c.codec.Marshal(need)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := url.QueryUnescape(values.Encode()); got != c.encode {
+			t.Fatalf("%s need: %s\ngot: %s", c.codec.Syntax, c.encode, got)
+		}
+		got := &form{}
+		p, err := c.codec.UnmarshalPresence(values, got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if jsonStr(got) != jsonStr(need) {
+			t.Fatalf("%s need: %s\ngot: %s", c.codec.Syntax, jsonStr(need), jsonStr(got))
+		}
+		for _, field := range []string{"user.str", "items[1].id", "meta.k2", "groups.g.id"} {
+			if !p.Has(field) {
+				t.Fatalf("%s need presence of %s", c.codec.Syntax, field)
+			}
+		}
+	}
+
+	// the indexes are ordered, the scalar items could be indexed and the embedded query string is supported
+	values, _ := url.ParseQuery("items[5][id]=2&items[1][id]=1&items[1][tags][1]=b&items[1][tags][0]=a&user=str%3DJay")
+	got := &form{}
+	err := schema.BracketCodec.Unmarshal(values, got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Items) != 2 || got.Items[0].ID != 1 || got.Items[1].ID != 2 || jsonStr(got.Items[0].Tags) != jsonStr([]string{"a", "b"}) {
+		t.Fatalf("got items: %s", jsonStr(got.Items))
+	}
+	if got.User == nil || got.User.Str != "Jay" {
+		t.Fatalf("got user: %s", jsonStr(got.User))
+	}
+	values, _ = url.ParseQuery("items[x][id]=1")
+	if err = schema.BracketCodec.Unmarshal(values, &form{}); err == nil {
+		t.Fatal("need error of the invalid index")
+	}
+}
@@ -5,8 +5,12 @@
 package schema
 
 import (
+	"fmt"
 	"github.com/orivil/types"
+	"math"
+	"reflect"
 	"regexp"
+	"strings"
 )
 
 type Validations struct {
@@ -22,11 +26,165 @@ type Validations struct {
 	MaxExcNum *float64 `json:"maxExcNum,omitempty"`
 	MinExcNum *float64 `json:"minExcNum,omitempty"`
 	Enum      []string `json:"enum,omitempty"`
+	// Integer makes the numbers integers, such as the JSON Schema type "integer"
+	Integer bool `json:"integer,omitempty"`
+	// RequiredMode is the mode of the Required rule, see RequiredPresent
+	RequiredMode string `json:"requiredMode,omitempty"`
+	// the conditional rules of Required which depend on the sibling fields, see Schema.WithRequiredIf
+	RequiredIf      *Condition `json:"requiredIf,omitempty"`
+	RequiredWith    []string   `json:"requiredWith,omitempty"`
+	RequiredWithout []string   `json:"requiredWithout,omitempty"`
+	// the comparison rules hold the paths of the other fields, see Schema.WithEqField, they are not
+	// exported to JSON Schema which has no keyword for them
+	EqField  string `json:"eqField,omitempty"`
+	NeField  string `json:"neField,omitempty"`
+	GtField  string `json:"gtField,omitempty"`
+	GteField string `json:"gteField,omitempty"`
+	LtField  string `json:"ltField,omitempty"`
+	LteField string `json:"lteField,omitempty"`
+	// the rules of maps, the numbers of the entries and the constraints of the keys, the length of the
+	// keys is counted by the LenUnit
+	MinProps   *int   `json:"minProps,omitempty"`
+	MaxProps   *int   `json:"maxProps,omitempty"`
+	KeyPattern string `json:"keyPattern,omitempty"`
+	KeyMinLen  *int   `json:"keyMinLen,omitempty"`
+	KeyMaxLen  *int   `json:"keyMaxLen,omitempty"`
+	// Strict makes the object reject the unknown properties, see Schema.WithStrict, it is also set by a
+	// failure of an unknown property
+	Strict bool `json:"strict,omitempty"`
+	// Message is the custom message of the failures, see Schema.WithMessage
+	Message string `json:"message,omitempty"`
+	// Use holds the names of the custom validators, see RegisterValidator
+	Use []string `json:"use,omitempty"`
+	// Format is the format of strings, such as FormatEmail
+	Format string `json:"format,omitempty"`
+	// LenUnit is the unit of MinLen and MaxLen for strings, see LenUnitRune
+	LenUnit string `json:"lenUnit,omitempty"`
+	// Const is the only valid value of booleans
+	Const *bool `json:"const,omitempty"`
+	// the rules of files, the sizes are in bytes and the image dimensions are in pixels
+	MaxSize   *int64   `json:"maxSize,omitempty"`
+	MinSize   *int64   `json:"minSize,omitempty"`
+	Mime      []string `json:"mime,omitempty"`
+	Ext       []string `json:"ext,omitempty"`
+	MaxWidth  *int     `json:"maxWidth,omitempty"`
+	MinWidth  *int     `json:"minWidth,omitempty"`
+	MaxHeight *int     `json:"maxHeight,omitempty"`
+	MinHeight *int     `json:"minHeight,omitempty"`
+	// Type is only set by a failure of a value which is not the type of the schema
+	Type JsonKind `json:"type,omitempty"`
+	// ValidSchema is only set by a failure of a SchemaValidator, it is the message of the error
+	ValidSchema string `json:"validSchema,omitempty"`
+	// Image is only set by a failure of a file which is not a supported image, the content is decoded
+	// by the rules of the image dimensions
+	Image bool `json:"image,omitempty"`
+}
+
+// RuleType is the rule name of a failure of a value which is not the type of the schema
+const RuleType = "type"
+
+// RuleValidSchema is the rule name of a failure of a SchemaValidator
+const RuleValidSchema = "validSchema"
+
+// RuleImage is the rule name of a failure of a file which is not a supported image
+const RuleImage = "image"
+
+// Rule returns the name of the first rule which is set, for a failure returned by Schema.Valid or
+// Schema.ValidAll it is the name of the failed rule, such as "required" or "minLen", or the name of
+// the failed custom validator
+func (vs *Validations) Rule() string {
+	switch {
+	case len(vs.Use) > 0:
+		return vs.Use[0]
+	case vs.Type != "":
+		return RuleType
+	case vs.ValidSchema != "":
+		return RuleValidSchema
+	case vs.Image:
+		return RuleImage
+	case vs.Required:
+		return OptionsRequired
+	case vs.Strict:
+		return OptionsStrict
+	case vs.RequiredIf != nil:
+		return RequiredIf
+	case vs.RequiredWith != nil:
+		return RequiredWith
+	case vs.RequiredWithout != nil:
+		return RequiredWithout
+	case vs.Enum != nil:
+		return Enum
+	case vs.Pattern != "":
+		return Pattern
+	case vs.Format != "":
+		return Format
+	case vs.Const != nil:
+		return Const
+	case vs.MinSize != nil:
+		return MinSize
+	case vs.MaxSize != nil:
+		return MaxSize
+	case vs.Mime != nil:
+		return Mime
+	case vs.Ext != nil:
+		return Ext
+	case vs.MinWidth != nil:
+		return MinWidth
+	case vs.MaxWidth != nil:
+		return MaxWidth
+	case vs.MinHeight != nil:
+		return MinHeight
+	case vs.MaxHeight != nil:
+		return MaxHeight
+	case vs.MinLen != nil:
+		return MinLen
+	case vs.MaxLen != nil:
+		return MaxLen
+	case vs.MinItems != nil:
+		return MinItems
+	case vs.MaxItems != nil:
+		return MaxItems
+	case vs.Integer:
+		return Integer
+	case vs.MinNum != nil:
+		return MinNum
+	case vs.MaxNum != nil:
+		return MaxNum
+	case vs.MinExcNum != nil:
+		return MinExcNum
+	case vs.MaxExcNum != nil:
+		return MaxExcNum
+	case vs.MinProps != nil:
+		return MinProps
+	case vs.MaxProps != nil:
+		return MaxProps
+	case vs.KeyPattern != "":
+		return KeyPattern
+	case vs.KeyMinLen != nil:
+		return KeyMinLen
+	case vs.KeyMaxLen != nil:
+		return KeyMaxLen
+	}
+	for _, rule := range fieldRules {
+		if vs.fieldRule(rule) != "" {
+			return rule
+		}
+	}
+	return ""
 }
 
 func (vs *Validations) validItemsLength(length int) *Validations {
-	if (vs.MinLen != nil && *vs.MinLen > length) || (vs.MaxLen != nil && *vs.MaxLen < length) {
-		return &Validations{MinItems: vs.MinItems, MaxItems: vs.MaxItems}
+	if vs.MinItems != nil && *vs.MinItems > length {
+		return &Validations{MinItems: vs.MinItems}
+	}
+	if vs.MaxItems != nil && *vs.MaxItems < length {
+		return &Validations{MaxItems: vs.MaxItems}
+	}
+	if vs.MinLen != nil && *vs.MinLen > length {
+		return &Validations{MinLen: vs.MinLen}
+	}
+	if vs.MaxLen != nil && *vs.MaxLen < length {
+		return &Validations{MaxLen: vs.MaxLen}
 	}
 	return nil
 }
@@ -50,6 +208,9 @@ func (vs *Validations) validNumber(num float64) (info *Validations, err error) {
 			return &Validations{Enum: vs.Enum}, nil
 		}
 	}
+	if vs.Integer && num != math.Trunc(num) {
+		return &Validations{Integer: true}, nil
+	}
 	if vs.MinNum != nil {
 		if *vs.MinNum > num {
 			return &Validations{MinNum: vs.MinNum}, nil
@@ -66,14 +227,14 @@ func (vs *Validations) validNumber(num float64) (info *Validations, err error) {
 		}
 	}
 	if vs.MaxExcNum != nil {
-		if *vs.MaxExcNum < num {
+		if *vs.MaxExcNum <= num {
 			return &Validations{MaxExcNum: vs.MaxExcNum}, nil
 		}
 	}
 	return nil, nil
 }
 
-func (vs *Validations) validString(str string) (info *Validations, err error) {
+func (vs *Validations) validString(str string, lenUnit string) (info *Validations, err error) {
 	if vs.Enum != nil {
 		exist := false
 		for _, e := range vs.Enum {
@@ -96,7 +257,10 @@ func (vs *Validations) validString(str string) (info *Validations, err error) {
 			return &Validations{Pattern: vs.Pattern}, nil
 		}
 	}
-	length := len(str)
+	if vs.Format != "" && !isFormat(vs.Format, str) {
+		return &Validations{Format: vs.Format}, nil
+	}
+	length := stringLength(str, lenUnit)
 	if vs.MinLen != nil && *vs.MinLen > length {
 		return &Validations{MinLen: vs.MinLen}, nil
 	}
@@ -105,3 +269,141 @@ func (vs *Validations) validString(str string) (info *Validations, err error) {
 	}
 	return nil, nil
 }
+
+// ValidationError is a failure of validating, it is returned as the error by the AsError option
+type ValidationError struct {
+	// Field is the path of the field, such as "user.tags[0]"
+	Field string
+	// Rule is the name of the failed rule, such as "minLen", see Validations.Rule
+	Rule string
+	// Expected is the constraint of the rule, such as 10 of "minLen:10"
+	Expected interface{}
+	// Actual is the value of the field, nil if the value is absent
+	Actual interface{}
+	// Description is the description of the field
+	Description string
+	// Message is the custom message of the field, see Schema.WithMessage
+	Message string
+
+	validations *Validations
+}
+
+func newValidationError(info *Validations, actual interface{}) *ValidationError {
+	rule := info.Rule()
+	return &ValidationError{
+		Field:       info.Field,
+		Rule:        rule,
+		Expected:    info.expected(rule),
+		Actual:      actual,
+		validations: info,
+	}
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("schema field [%s] failed rule %s, expected: %v, got: %v", e.Field, e.Rule, e.Expected, e.Actual)
+}
+
+// Validations returns the failure as a Validations which is returned by Schema.Valid
+func (e *ValidationError) Validations() *Validations {
+	return e.validations
+}
+
+// ValidationErrors is all the failures of validating, it is returned as the error by Schema.ValidAll
+// with the AsError option
+type ValidationErrors []*ValidationError
+
+func (es ValidationErrors) Error() string {
+	msgs := make([]string, len(es))
+	for i, e := range es {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (es ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(es))
+	for i, e := range es {
+		errs[i] = e
+	}
+	return errs
+}
+
+// expected returns the constraint of the rule
+func (vs *Validations) expected(rule string) interface{} {
+	switch rule {
+	case RuleType:
+		return vs.Type
+	case RuleValidSchema:
+		return vs.ValidSchema
+	case OptionsRequired, OptionsStrict, Integer, RuleImage:
+		return true
+	case RequiredIf:
+		return *vs.RequiredIf
+	case RequiredWith:
+		return vs.RequiredWith
+	case RequiredWithout:
+		return vs.RequiredWithout
+	case Enum:
+		return vs.Enum
+	case Pattern:
+		return vs.Pattern
+	case Format:
+		return vs.Format
+	case Const:
+		return *vs.Const
+	case MinSize:
+		return *vs.MinSize
+	case MaxSize:
+		return *vs.MaxSize
+	case Mime:
+		return vs.Mime
+	case Ext:
+		return vs.Ext
+	case MinWidth:
+		return *vs.MinWidth
+	case MaxWidth:
+		return *vs.MaxWidth
+	case MinHeight:
+		return *vs.MinHeight
+	case MaxHeight:
+		return *vs.MaxHeight
+	case MinLen:
+		return *vs.MinLen
+	case MaxLen:
+		return *vs.MaxLen
+	case MinItems:
+		return *vs.MinItems
+	case MaxItems:
+		return *vs.MaxItems
+	case MinNum:
+		return *vs.MinNum
+	case MaxNum:
+		return *vs.MaxNum
+	case MinExcNum:
+		return *vs.MinExcNum
+	case MaxExcNum:
+		return *vs.MaxExcNum
+	case MinProps:
+		return *vs.MinProps
+	case MaxProps:
+		return *vs.MaxProps
+	case KeyPattern:
+		return vs.KeyPattern
+	case KeyMinLen:
+		return *vs.KeyMinLen
+	case KeyMaxLen:
+		return *vs.KeyMaxLen
+	case EqField, NeField, GtField, GteField, LtField, LteField:
+		return vs.fieldRule(rule)
+	}
+	return nil
+}
+
+func lengthOf(v interface{}) int {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array, reflect.String, reflect.Map:
+		return rv.Len()
+	}
+	return 0
+}
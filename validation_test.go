@@ -5,9 +5,13 @@
 package schema_test
 
 import (
+	"errors"
 	. "github.com/orivil/schema"
+	"net/url"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestValidate(t *testing.T) {
@@ -40,11 +44,15 @@ func TestValidate(t *testing.T) {
 		{"int", `schema:"minNum:2"`, model{}, nil},
 		{"int", `schema:"minNum:2"`, model{Int: 1}, &Validations{Field: "int", MinNum: newFloat(2)}},
 		{"int", `schema:"maxNum:2"`, model{Int: 3}, &Validations{Field: "int", MaxNum: newFloat(2)}},
+		{"int", `schema:"maxExcNum:3"`, model{Int: 2}, nil},
+		{"int", `schema:"maxExcNum:3"`, model{Int: 3}, &Validations{Field: "int", MaxExcNum: newFloat(3)}},
+		{"int", `schema:"minExcNum:2"`, model{Int: 2}, &Validations{Field: "int", MinExcNum: newFloat(2)}},
 		{"int8", `schema:"required"`, model{}, &Validations{Field: "int8", Required: true}},
 		{"s_str", `schema:"required"`, model{}, &Validations{Field: "s_str", Required: true}},
 		{"s_str", `schema:"minItems:2"`, model{Anonymous: &Anonymous{SStr: []string{"1", "2"}}}, nil},
 		{"s_str", `schema:"maxItems:2"`, model{Anonymous: &Anonymous{SStr: []string{"1", "2"}}}, nil},
-		{"s_str", `schema:"minItems:2;maxItems:3"`, model{Anonymous: &Anonymous{SStr: []string{"1", "2", "3", "4"}}}, &Validations{Field: "s_str", MinItems: newInt(2), MaxItems: newInt(3)}},
+		{"s_str", `schema:"minItems:2;maxItems:3"`, model{Anonymous: &Anonymous{SStr: []string{"1", "2", "3", "4"}}}, &Validations{Field: "s_str", MaxItems: newInt(3)}},
+		{"s_str", `schema:"minItems:2;maxItems:3"`, model{Anonymous: &Anonymous{SStr: []string{"1"}}}, &Validations{Field: "s_str", MinItems: newInt(2)}},
 		{"s_int", `schema:"required"`, model{}, &Validations{Field: "s_int", Required: true}},
 	}
 	for _, tc := range testCases {
@@ -74,3 +82,691 @@ func TestValidate(t *testing.T) {
 func newFloat(f float64) *float64 {
 	return &f
 }
+
+func TestValidAll(t *testing.T) {
+	type item struct {
+		Name string `json:"name" schema:"required"`
+	}
+	type other struct {
+		Name string `json:"name" schema:"required"`
+	}
+	type model struct {
+		Str   string  `json:"str" schema:"minLen:2"`
+		Int   int     `json:"int" schema:"maxNum:2"`
+		Items []*item `json:"items"`
+		Other *other  `json:"other"`
+	}
+	schema, err := NewSchema(&model{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	infos, err := schema.ValidAll(&model{Str: "1", Int: 3, Items: []*item{{Name: "a"}, {}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	need := [][2]string{
+		{"str", MinLen},
+		{"int", MaxNum},
+		{"items[1].name", OptionsRequired},
+		{"other.name", OptionsRequired},
+	}
+	if len(infos) != len(need) {
+		t.Fatalf("need %d failures, got: %s", len(need), jsonStr(infos))
+	}
+	for i, info := range infos {
+		if info.Field != need[i][0] || info.Rule() != need[i][1] {
+			t.Errorf("need: %v, got: %s %s\n", need[i], info.Field, info.Rule())
+		}
+	}
+	info, err := schema.Valid(&model{Str: "1", Int: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil || info.Field != "str" {
+		t.Errorf("need first failure of field str, got: %s", jsonStr(info))
+	}
+}
+
+func TestRequiredPresent(t *testing.T) {
+	type model struct {
+		Count int    `json:"count" schema:"required:present;maxNum:10"`
+		Flag  *bool  `json:"flag" schema:"required:present"`
+		Name  string `json:"name" schema:"required:nonzero"`
+	}
+	schema, err := NewSchema(&model{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps := &model{}
+	presence, err := UnmarshalUrlPresence(url.Values{"count": {"0"}, "flag": {"false"}, "name": {"Nina"}}, ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	infos, err := schema.ValidAll(ps, WithPresence(presence))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) > 0 {
+		t.Fatalf("need no failures, got: %s", jsonStr(infos))
+	}
+	ps = &model{}
+	presence, err = UnmarshalUrlPresence(url.Values{"name": {""}}, ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	infos, err = schema.ValidAll(ps, WithPresence(presence))
+	if err != nil {
+		t.Fatal(err)
+	}
+	need := []string{"count", "flag", "name"}
+	if len(infos) != len(need) {
+		t.Fatalf("need failures of %v, got: %s", need, jsonStr(infos))
+	}
+	for i, info := range infos {
+		if info.Field != need[i] || !info.Required {
+			t.Errorf("need required failure of %s, got: %s", need[i], jsonStr(info))
+		}
+	}
+
+	// the properties inherit the mode of the object
+	schema, err = NewSchema(map[string]interface{}{"a": 0, "b": false})
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema.WithRequiredMode(RequiredPresent).Requires([]string{"a", "b"})
+	infos, err = schema.ValidAll(map[string]interface{}{"a": 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 || infos[0].Field != "b" {
+		t.Fatalf("need required failure of b, got: %s", jsonStr(infos))
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	type model struct {
+		Name string   `json:"name" schema:"minLen:3"`
+		Tags []string `json:"tags" schema:"minItems:1;maxItems:2"`
+	}
+	schema, err := NewSchema(&model{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps := &model{Name: "ab", Tags: []string{"1", "2", "3"}}
+	info, err := schema.Valid(ps, AsError())
+	if info != nil {
+		t.Fatalf("need nil validations, got: %s", jsonStr(info))
+	}
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("need *ValidationError, got: %v", err)
+	}
+	if ve.Field != "name" || ve.Rule != MinLen || ve.Expected != 3 || ve.Actual != "ab" {
+		t.Errorf("got: %+v", ve)
+	}
+	if ve.Error() != "schema field [name] failed rule minLen, expected: 3, got: ab" {
+		t.Errorf("got message: %s", ve.Error())
+	}
+	_, err = schema.ValidAll(ps, AsError())
+	var ves ValidationErrors
+	if !errors.As(err, &ves) || len(ves) != 2 {
+		t.Fatalf("need 2 ValidationErrors, got: %v", err)
+	}
+	if ves[1].Field != "tags" || ves[1].Rule != MaxItems || ves[1].Expected != 2 {
+		t.Errorf("got: %+v", ves[1])
+	}
+	if !errors.As(err, &ve) || ve != ves[0] {
+		t.Errorf("need the first *ValidationError, got: %v", ve)
+	}
+}
+
+func TestCustomValidators(t *testing.T) {
+	RegisterValidator("mobile", func(v interface{}) bool {
+		str, ok := v.(string)
+		return ok && len(str) == 11 && str[0] == '1'
+	})
+	RegisterValidator("notBlank", func(v interface{}) bool {
+		str, _ := v.(string)
+		return strings.TrimSpace(str) != ""
+	})
+	type period struct {
+		Start int `json:"start"`
+		End   int `json:"end"`
+	}
+	RegisterValidator("period", func(v interface{}) bool {
+		p := v.(period)
+		return p.Start <= p.End
+	})
+	type model struct {
+		Mobile string `json:"mobile" schema:"required; use:mobile"`
+		Name   string `json:"name" schema:"use:notBlank, mobile"`
+		Period period `json:"period" schema:"use:period"`
+	}
+	schema, err := NewSchema(&model{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := schema.Property("name").Validations.Use; len(got) != 2 || got[1] != "mobile" {
+		t.Fatalf("need validators [notBlank mobile], got: %v", got)
+	}
+	_, err = schema.ValidAll(&model{Mobile: "12345", Name: " ", Period: period{Start: 2, End: 1}}, AsError())
+	var es ValidationErrors
+	if !errors.As(err, &es) {
+		t.Fatalf("need ValidationErrors, got: %v", err)
+	}
+	need := [][2]string{{"mobile", "mobile"}, {"name", "notBlank"}, {"period", "period"}}
+	if len(es) != len(need) {
+		t.Fatalf("need %d failures, got: %v", len(need), err)
+	}
+	for i, e := range es {
+		if e.Field != need[i][0] || e.Rule != need[i][1] {
+			t.Errorf("need: %v, got: %s %s", need[i], e.Field, e.Rule)
+		}
+	}
+	if got := es[0].Localize(LocaleEnUS); got != "mobile is invalid" {
+		t.Errorf("got message: %s", got)
+	}
+	info, err := schema.Valid(&model{Mobile: "13800000000", Name: "13800000001"})
+	if err != nil || info != nil {
+		t.Fatalf("need no failure, got: %v, %v", info, err)
+	}
+	// the validators of a repeated struct are run on its Ref schema
+	type booking struct {
+		Stay   period `json:"stay" schema:"use:period"`
+		Return period `json:"return" schema:"use:period"`
+	}
+	bs, err := NewSchema(&booking{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bs.Property("return").Ref == "" {
+		t.Fatalf("need the Ref schema of return, got: %s", jsonStr(bs))
+	}
+	infos, err := bs.ValidAll(&booking{Stay: period{Start: 1, End: 2}, Return: period{Start: 2, End: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 || infos[0].Field != "return" || infos[0].Rule() != "period" {
+		t.Fatalf("need period failure of return, got: %s", jsonStr(infos))
+	}
+	err = schema.Property("name").WithTagOptions(`schema:"use:unknown"`)
+	if _, ok := err.(*TagError); !ok {
+		t.Errorf("need *TagError, got: %v", err)
+	}
+}
+
+func TestLenUnit(t *testing.T) {
+	type model struct {
+		Nickname string `json:"nickname" schema:"maxLen:4"`
+		Username string `json:"username" schema:"maxLen:4;lenUnit:byte"`
+	}
+	schema, err := NewSchema(&model{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema.WithLenUnit(LenUnitRune)
+	infos, err := schema.ValidAll(&model{Nickname: "周杰伦", Username: "周杰伦"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 || infos[0].Field != "username" {
+		t.Fatalf("need maxLen failure of username, got: %s", jsonStr(infos))
+	}
+	if got := jsonStr(schema.Property("username").Validations); !strings.Contains(got, `"lenUnit": "byte"`) {
+		t.Errorf("need lenUnit in validations, got: %s", got)
+	}
+}
+
+func TestConditionalRequired(t *testing.T) {
+	type account struct {
+		Type     int     `json:"type"`
+		Company  string  `json:"company" schema:"requiredIf:type=2,3" desc:"company"`
+		Email    string  `json:"email" schema:"requiredWithout:mobile"`
+		Mobile   string  `json:"mobile" schema:"requiredWithout:email"`
+		Password string  `json:"password"`
+		Confirm  *string `json:"confirm" schema:"requiredWith:password"`
+	}
+	schema, err := NewSchema(&account{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		v    *account
+		need [][2]string
+	}{
+		{&account{Type: 1, Email: "a@b.c"}, nil},
+		{&account{Type: 2, Mobile: "13800000000"}, [][2]string{{"company", RequiredIf}}},
+		{&account{Type: 3, Company: "orivil"}, [][2]string{{"email", RequiredWithout}, {"mobile", RequiredWithout}}},
+		{&account{Email: "a@b.c", Password: "secret"}, [][2]string{{"confirm", RequiredWith}}},
+	}
+	for i, c := range cases {
+		_, err = schema.ValidAll(c.v, AsError())
+		var es ValidationErrors
+		if c.need == nil {
+			if err != nil {
+				t.Errorf("case %d need no failure, got: %v", i, err)
+			}
+			continue
+		}
+		if !errors.As(err, &es) || len(es) != len(c.need) {
+			t.Fatalf("case %d need %d failures, got: %v", i, len(c.need), err)
+		}
+		for j, e := range es {
+			if e.Field != c.need[j][0] || e.Rule != c.need[j][1] {
+				t.Errorf("case %d need: %v, got: %s %s", i, c.need[j], e.Field, e.Rule)
+			}
+		}
+	}
+	_, err = schema.Valid(&account{Type: 2, Email: "a@b.c"}, AsError())
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("need *ValidationError, got: %v", err)
+	}
+	if got := ve.Localize(LocaleEnUS); got != "company is required when type is 2 or 3" {
+		t.Errorf("got message: %s", got)
+	}
+	if got := ve.Localize(LocaleZhCN); got != "type为2或3时company不能为空" {
+		t.Errorf("got message: %s", got)
+	}
+	if got := ve.Error(); got != "schema field [company] failed rule requiredIf, expected: type=2,3, got: " {
+		t.Errorf("got error: %s", got)
+	}
+	got := jsonStr(schema.Property("company").Validations)
+	if !strings.Contains(got, `"requiredIf": {`) || !strings.Contains(got, `"field": "type"`) {
+		t.Errorf("need requiredIf in validations, got: %s", got)
+	}
+
+	// the rules of a map are checked by the built schema
+	ms := &Schema{Type: Object, Properties: Properties{
+		{Name: "email", Type: String},
+		(&Schema{Name: "mobile", Type: String}).WithRequiredWithout("email"),
+	}}
+	info, err := ms.Valid(map[string]string{"email": ""})
+	if err != nil || info == nil || info.Field != "mobile" || info.Rule() != RequiredWithout {
+		t.Fatalf("need requiredWithout failure of mobile, got: %s, %v", jsonStr(info), err)
+	}
+
+	type unknown struct {
+		Name string `json:"name" schema:"requiredWith:nickname"`
+	}
+	_, err = NewSchema(&unknown{})
+	if te, ok := err.(*TagError); !ok || te.Tag != Tag+"."+RequiredWith {
+		t.Errorf("need *TagError of requiredWith, got: %v", err)
+	}
+	_, err = NewSchema(&struct {
+		Name string `json:"name" schema:"requiredIf:type"`
+	}{})
+	if _, ok := err.(*TagError); !ok {
+		t.Errorf("need *TagError of requiredIf, got: %v", err)
+	}
+}
+
+func TestFieldComparison(t *testing.T) {
+	type period struct {
+		Start time.Time `json:"start"`
+		End   time.Time `json:"end" schema:"gtField:start"`
+	}
+	type item struct {
+		Price float64 `json:"price" schema:"lteField:$.max"`
+	}
+	type form struct {
+		Password string     `json:"password"`
+		Confirm  string     `json:"password_confirm" schema:"eqField:password" desc:"confirm password"`
+		Min      int        `json:"min"`
+		Max      int        `json:"max" schema:"gteField:min"`
+		Period   period     `json:"period"`
+		Deadline *time.Time `json:"deadline" schema:"gtField:period.end"`
+		Items    []item     `json:"items"`
+	}
+	schema, err := NewSchema(&form{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	later := now.Add(time.Hour)
+	valid := func() *form {
+		return &form{
+			Password: "secret",
+			Confirm:  "secret",
+			Min:      1,
+			Max:      10,
+			Period:   period{Start: now, End: later},
+			Deadline: &[]time.Time{later.Add(time.Hour)}[0],
+			Items:    []item{{Price: 10}},
+		}
+	}
+	cases := []struct {
+		update   func(f *form)
+		field    string
+		rule     string
+		expected string
+	}{
+		{func(f *form) {}, "", "", ""},
+		{func(f *form) { f.Confirm = "secrets" }, "password_confirm", EqField, "password"},
+		{func(f *form) { f.Max, f.Items = 0, nil }, "", "", ""},
+		{func(f *form) { f.Max = -1 }, "max", GteField, "min"},
+		{func(f *form) { f.Period.End = now }, "period.end", GtField, "period.start"},
+		{func(f *form) { f.Deadline = &now }, "deadline", GtField, "period.end"},
+		{func(f *form) { f.Deadline = nil }, "", "", ""},
+		{func(f *form) { f.Items = append(f.Items, item{Price: 11}) }, "items[1].price", LteField, "max"},
+	}
+	for i, c := range cases {
+		f := valid()
+		c.update(f)
+		_, err = schema.Valid(f, AsError())
+		var ve *ValidationError
+		if c.rule == "" {
+			if err != nil {
+				t.Errorf("case %d need no failure, got: %v", i, err)
+			}
+			continue
+		}
+		if !errors.As(err, &ve) || ve.Field != c.field || ve.Rule != c.rule || ve.Expected != c.expected {
+			t.Errorf("case %d need %s %s %s, got: %v", i, c.field, c.rule, c.expected, err)
+		}
+	}
+	f := valid()
+	f.Confirm = "secrets"
+	_, err = schema.Valid(f, AsError())
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("need *ValidationError, got: %v", err)
+	}
+	if got := ve.Localize(LocaleEnUS); got != "confirm password must be equal to password" {
+		t.Errorf("got message: %s", got)
+	}
+
+	// the strings are compared with the RFC 3339 times
+	ms := &Schema{Type: Object, Properties: Properties{
+		{Name: "start", Type: String},
+		(&Schema{Name: "end", Type: String}).WithGtField("start"),
+	}}
+	info, err := ms.Valid(map[string]string{"start": "2020-01-02T00:00:00Z", "end": "2020-01-01T00:00:00Z"})
+	if err != nil || info == nil || info.Rule() != GtField || info.GtField != "start" {
+		t.Fatalf("need gtField failure, got: %s, %v", jsonStr(info), err)
+	}
+	// the values of different kinds are failures of the payload, the values which could not be compared
+	// are errors of the schema
+	info, err = ms.Valid(map[string]interface{}{"start": 1, "end": "2"})
+	if err != nil || info == nil || info.Rule() != GtField {
+		t.Errorf("need gtField failure of comparing a string with a number, got: %s, %v", jsonStr(info), err)
+	}
+	as := &Schema{Type: Object, Properties: Properties{
+		{Name: "a", Type: Array},
+		(&Schema{Name: "b", Type: Array}).WithEqField("a"),
+	}}
+	_, err = as.Valid(map[string]interface{}{"a": []int{1}, "b": []int{1}})
+	if err == nil {
+		t.Error("need error of comparing the arrays")
+	}
+
+	_, err = NewSchema(&struct {
+		Name string `json:"name" schema:"eqField:nickname.first"`
+	}{})
+	if te, ok := err.(*TagError); !ok || te.Tag != Tag+"."+EqField {
+		t.Errorf("need *TagError of eqField, got: %v", err)
+	}
+}
+
+type hookPeriod struct {
+	Start int `json:"start" schema:"minNum:0"`
+	End   int `json:"end"`
+}
+
+func (p hookPeriod) ValidSchema() error {
+	if p.End < p.Start {
+		return errors.New("end must be after start")
+	}
+	return nil
+}
+
+type hookItem struct {
+	Qty int `json:"qty"`
+}
+
+func (i *hookItem) ValidSchema() error {
+	if i.Qty > 10 {
+		return ValidationErrors{{Field: "qty", Rule: "stock", Expected: 10, Actual: i.Qty}}
+	}
+	return nil
+}
+
+type hookBooking struct {
+	Name     string      `json:"name" schema:"required"`
+	Period   hookPeriod  `json:"period" desc:"booking period"`
+	Optional *hookPeriod `json:"optional"`
+	Items    []hookItem  `json:"items"`
+}
+
+func (b *hookBooking) ValidSchema() error {
+	if b.Name == "admin" {
+		return &ValidationError{Field: "name", Message: "{{.Field}} is reserved"}
+	}
+	return nil
+}
+
+func TestSchemaValidator(t *testing.T) {
+	schema, err := NewSchema(&hookBooking{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		v    *hookBooking
+		need [][2]string
+	}{
+		{&hookBooking{Name: "Nina", Period: hookPeriod{End: 1}}, nil},
+		{&hookBooking{Name: "Nina", Period: hookPeriod{Start: 2, End: 1}}, [][2]string{{"period", RuleValidSchema}}},
+		// the struct is not validated if the rules of its fields fail
+		{&hookBooking{Name: "Nina", Period: hookPeriod{Start: -1, End: -2}}, [][2]string{{"period.start", MinNum}}},
+		{&hookBooking{Name: "Nina", Optional: &hookPeriod{Start: 1}}, [][2]string{{"optional", RuleValidSchema}}},
+		{&hookBooking{Name: "Nina", Items: []hookItem{{Qty: 1}, {Qty: 11}}}, [][2]string{{"items[1].qty", "stock"}}},
+		{&hookBooking{Name: "admin"}, [][2]string{{"name", RuleValidSchema}}},
+		{&hookBooking{Period: hookPeriod{Start: 2}, Items: []hookItem{{Qty: 11}}}, [][2]string{
+			{"name", OptionsRequired}, {"period", RuleValidSchema}, {"items[0].qty", "stock"},
+		}},
+	}
+	for i, c := range cases {
+		_, err = schema.ValidAll(c.v, AsError())
+		var es ValidationErrors
+		if c.need == nil {
+			if err != nil {
+				t.Errorf("case %d need no failure, got: %v", i, err)
+			}
+			continue
+		}
+		if !errors.As(err, &es) || len(es) != len(c.need) {
+			t.Fatalf("case %d need %d failures, got: %v", i, len(c.need), err)
+		}
+		for j, e := range es {
+			if e.Field != c.need[j][0] || e.Rule != c.need[j][1] {
+				t.Errorf("case %d need: %v, got: %s %s", i, c.need[j], e.Field, e.Rule)
+			}
+		}
+	}
+	_, err = schema.Valid(cases[1].v, AsError())
+	var ve *ValidationError
+	if !errors.As(err, &ve) || ve.Expected != "end must be after start" || ve.Description != "booking period" {
+		t.Fatalf("need the failure of the period, got: %+v", ve)
+	}
+	if got := ve.Localize(LocaleZhCN); got != "end must be after start" {
+		t.Errorf("got message: %s", got)
+	}
+	_, err = schema.Valid(cases[5].v, AsError())
+	if !errors.As(err, &ve) || ve.Localize(LocaleEnUS) != "name is reserved" {
+		t.Errorf("need the custom message of name, got: %v", err)
+	}
+	info, err := schema.Valid(cases[4].v)
+	if err != nil || info == nil || info.Field != "items[1].qty" || info.Rule() != "stock" {
+		t.Errorf("need stock failure of items[1].qty, got: %s, %v", jsonStr(info), err)
+	}
+}
+
+func TestMapRules(t *testing.T) {
+	type item struct {
+		Name  string `json:"name" schema:"required"`
+		Price int    `json:"price" schema:"minNum:1"`
+	}
+	type shop struct {
+		Items  map[string]item   `json:"items" schema:"minProps:1;maxProps:3;keyPattern:^[a-z]+$"`
+		Labels map[string]string `json:"labels" schema:"keyMaxLen:3;lenUnit:rune" schemaValue:"required;maxLen:4"`
+	}
+	schema, err := NewSchema(&shop{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := map[string]item{"apple": {Name: "apple", Price: 2}}
+	cases := []struct {
+		v      *shop
+		field  string
+		rule   string
+		actual interface{}
+	}{
+		{&shop{Items: valid, Labels: map[string]string{"色": "红"}}, "", "", nil},
+		{&shop{Items: map[string]item{}}, "items", MinProps, nil},
+		{&shop{Items: map[string]item{"a": {}, "b": {}, "c": {}, "d": {}}}, "items", MaxProps, nil},
+		{&shop{Items: map[string]item{"A1": {Name: "a", Price: 1}}}, "items", KeyPattern, "A1"},
+		{&shop{Items: map[string]item{"apple": {Price: 2}}}, "items.apple.name", OptionsRequired, nil},
+		{&shop{Items: map[string]item{"apple": {Name: "apple", Price: -1}, "pear": {Name: "pear", Price: 1}}}, "items.apple.price", MinNum, nil},
+		{&shop{Items: valid, Labels: map[string]string{"size": "L"}}, "labels", KeyMaxLen, "size"},
+		{&shop{Items: valid, Labels: map[string]string{"b": "", "a": "large"}}, "labels.a", MaxLen, nil},
+	}
+	for i, c := range cases {
+		_, err = schema.Valid(c.v, AsError())
+		if c.rule == "" {
+			if err != nil {
+				t.Errorf("case %d need no failure, got: %v", i, err)
+			}
+			continue
+		}
+		var ve *ValidationError
+		if !errors.As(err, &ve) || ve.Field != c.field || ve.Rule != c.rule {
+			t.Errorf("case %d need %s %s, got: %v", i, c.field, c.rule, err)
+			continue
+		}
+		if c.actual != nil && ve.Actual != c.actual {
+			t.Errorf("case %d need actual %v, got: %v", i, c.actual, ve.Actual)
+		}
+	}
+
+	// every entry is walked by ValidAll in the order of the keys
+	infos, err := schema.ValidAll(&shop{Items: valid, Labels: map[string]string{"b": "", "a": "large"}})
+	if err != nil || len(infos) != 2 || infos[0].Field != "labels.a" || infos[1].Field != "labels.b" {
+		t.Errorf("need failures of labels.a and labels.b, got: %s, %v", jsonStr(infos), err)
+	}
+	_, err = schema.Valid(&shop{Items: map[string]item{"A1": {}}}, AsError())
+	var ve *ValidationError
+	if !errors.As(err, &ve) || ve.Localize(LocaleEnUS) != "the key A1 of items does not match the pattern ^[a-z]+$" {
+		t.Errorf("got error: %v", err)
+	}
+
+	ms := (&Schema{Type: Object}).WithAdditionalProperties((&Schema{Type: Number}).WithMaxNum(10)).WithKeyMinLen(2)
+	info, err := ms.Valid(map[string]int{"ab": 11})
+	if err != nil || info == nil || info.Field != "ab" || info.Rule() != MaxNum {
+		t.Errorf("need maxNum failure of ab, got: %s, %v", jsonStr(info), err)
+	}
+	info, err = ms.Valid(map[string]int{"a": 1})
+	if err != nil || info == nil || info.Rule() != KeyMinLen {
+		t.Errorf("need keyMinLen failure, got: %s, %v", jsonStr(info), err)
+	}
+
+	_, err = NewSchema(&struct {
+		Labels map[string]string `json:"labels" schemaValue:"maxLen:x"`
+	}{})
+	if te, ok := err.(*TagError); !ok || te.Tag != ValueTag+"."+MaxLen {
+		t.Errorf("need *TagError of schemaValue.maxLen, got: %v", err)
+	}
+	_, err = NewSchema(&struct {
+		Items map[string]int `json:"items" schema:"keyPattern:("`
+	}{})
+	if te, ok := err.(*TagError); !ok || te.Tag != Tag+"."+KeyPattern {
+		t.Errorf("need *TagError of schema.keyPattern, got: %v", err)
+	}
+}
+
+func TestStrict(t *testing.T) {
+	type profile struct {
+		Nick string `json:"nick"`
+	}
+	type user struct {
+		Name    string                 `json:"name"`
+		Profile profile                `json:"profile" schema:"strict"`
+		Extra   profile                `json:"extra"`
+		Meta    map[string]interface{} `json:"meta"`
+	}
+	schema, err := NewSchema(&user{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		input string
+		opts  []ValidOption
+		need  []string
+	}{
+		{`{"name": "a", "age": 1, "extra": {"bio": ""}, "meta": {"k": 1}}`, nil, nil},
+		{`{"name": "a", "profile": {"nick": "b", "bio": "c"}, "extra": {"bio": ""}}`, nil, []string{"profile.bio"}},
+		{`{"name": "a", "age": 1, "extra": {"bio": ""}, "meta": {"k": 1}}`, []ValidOption{Strict()}, []string{"age", "extra.bio"}},
+	}
+	for i, c := range cases {
+		dec := NewJSONDecoder(strings.NewReader(c.input), schema)
+		infos, err := dec.DecodeAll(nil, c.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, info := range infos {
+			if info.Rule() != OptionsStrict {
+				t.Errorf("case %d need strict failure, got: %s", i, jsonStr(info))
+			}
+			got = append(got, info.Field)
+		}
+		if !reflect.DeepEqual(got, c.need) {
+			t.Errorf("case %d need: %v, got: %v", i, c.need, got)
+		}
+	}
+
+	// the other keys of a Go map are validated by the schema of the map values
+	type labeled struct {
+		Labels map[string]string `json:"labels" schemaValue:"maxLen:3"`
+	}
+	ls, err := NewSchema(&labeled{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	infos, err := ls.ValidAll(&labeled{Labels: map[string]string{"env": "dev", "tier": "back"}}, Strict())
+	if err != nil || len(infos) != 1 || infos[0].Field != "labels.tier" || infos[0].Rule() != MaxLen {
+		t.Fatalf("need maxLen failure of labels.tier, got: %s, %v", jsonStr(infos), err)
+	}
+	// a map without the schema of the values only allows the properties
+	ms, err := NewSchema(map[string]interface{}{"a": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	infos, err = ms.WithAdditionalProperties(nil).ValidAll(map[string]interface{}{"a": 1, "zzz": 2}, Strict())
+	if err != nil || len(infos) != 1 || infos[0].Field != "zzz" || infos[0].Rule() != OptionsStrict {
+		t.Fatalf("need strict failure of zzz, got: %s, %v", jsonStr(infos), err)
+	}
+
+	// the unknown query params are known by the presence
+	v := &user{}
+	p, err := DotCodec.UnmarshalPresence(url.Values{"name": {"a"}, "emial": {"b"}, "profile.bio": {"c"}}, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	infos, err = schema.ValidAll(v, WithPresence(p))
+	if err != nil || len(infos) != 1 || infos[0].Field != "profile.bio" {
+		t.Fatalf("need strict failure of profile.bio, got: %s, %v", jsonStr(infos), err)
+	}
+	_, err = schema.Valid(v, WithPresence(p), Strict(), AsError())
+	var ve *ValidationError
+	if !errors.As(err, &ve) || ve.Field != "emial" || ve.Localize(LocaleEnUS) != "emial is not allowed" {
+		t.Fatalf("need strict failure of emial, got: %v", err)
+	}
+	p, err = UnmarshalUrlPresence(url.Values{"name": {"a"}, "page": {"1"}}, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := schema.WithStrict(true).Valid(v, WithPresence(p))
+	if err != nil || info == nil || info.Field != "page" || info.Rule() != OptionsStrict {
+		t.Fatalf("need strict failure of page, got: %s, %v", jsonStr(info), err)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2020 orivil.com. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found at https://mit-license.org.
+
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ValidatorFunc is a custom validator, v is the indirect value of the field, such as a string, a number,
+// a slice or a struct, it reports whether v is valid
+type ValidatorFunc func(v interface{}) bool
+
+var validators sync.Map
+
+// RegisterValidator registers a custom validator, which is used by the "use" option of the schema tag,
+// such as `schema:"required; use:mobile"`, the validators should be registered before building schemas
+func RegisterValidator(name string, fn ValidatorFunc) {
+	validators.Store(name, fn)
+}
+
+func getValidator(name string) ValidatorFunc {
+	if fn, ok := validators.Load(name); ok {
+		return fn.(ValidatorFunc)
+	}
+	return nil
+}
+
+// validByValidators returns the name of the first failed validator
+func validByValidators(names []string, v interface{}) string {
+	for _, name := range names {
+		fn := getValidator(name)
+		if fn != nil && !fn(v) {
+			return name
+		}
+	}
+	return ""
+}
+
+// SchemaValidator validates the invariants of a struct which involve many fields, it is called by
+// Schema.Valid for the structs and the nested structs after the rules of their fields pass, the structs
+// of nil pointers are not validated. A returned *ValidationError or ValidationErrors is the failure of
+// the fields of the struct, its Field is prefixed with the path of the struct, other errors are the
+// failures of the struct itself by the "validSchema" rule
+type SchemaValidator interface {
+	ValidSchema() error
+}
+
+var schemaValidatorType = reflect.TypeOf(new(SchemaValidator)).Elem()
+
+// validSchema calls the SchemaValidator of the struct v
+func (vr *validator) validSchema(s *Schema, field string, v reflect.Value) {
+	if !v.CanInterface() {
+		return
+	}
+	var sv SchemaValidator
+	switch {
+	case v.CanAddr() && v.Addr().Type().Implements(schemaValidatorType):
+		sv = v.Addr().Interface().(SchemaValidator)
+	case v.Type().Implements(schemaValidatorType):
+		sv = v.Interface().(SchemaValidator)
+	case reflect.PtrTo(v.Type()).Implements(schemaValidatorType):
+		pv := reflect.New(v.Type())
+		pv.Elem().Set(v)
+		sv = pv.Interface().(SchemaValidator)
+	default:
+		return
+	}
+	vr.failSchema(s, field, sv.ValidSchema(), v.Interface())
+}
+
+// failSchema adds the failures of the error returned by a SchemaValidator of the struct field
+func (vr *validator) failSchema(s *Schema, field string, err error, actual interface{}) {
+	if err == nil {
+		return
+	}
+	var es ValidationErrors
+	switch e := err.(type) {
+	case *ValidationError:
+		es = ValidationErrors{e}
+	case ValidationErrors:
+		es = e
+	default:
+		vr.fail(s, field, &Validations{ValidSchema: err.Error()}, actual)
+		return
+	}
+	for _, e := range es {
+		// the failure is copied since the error could be shared
+		fe := *e
+		if e.Field == "" {
+			fe.Field = field
+		} else {
+			fe.Field = initFieldName(field, e.Field)
+		}
+		if fe.Rule == "" {
+			fe.Rule = RuleValidSchema
+		}
+		if fe.Description == "" && fe.Field == field {
+			fe.Description = s.Description
+		}
+		if e.validations != nil {
+			info := *e.validations
+			fe.validations = &info
+		} else if fe.Rule != RuleValidSchema {
+			// the custom rule is held as a custom validator
+			fe.validations = &Validations{Use: []string{fe.Rule}, Message: e.Message}
+		} else {
+			msg := e.Message
+			if msg == "" {
+				msg = fmt.Sprint(e.Expected)
+			}
+			fe.validations = &Validations{ValidSchema: msg}
+			if fe.Expected == nil {
+				fe.Expected = msg
+			}
+		}
+		fe.validations.Field = fe.Field
+		vr.failures = append(vr.failures, &fe)
+	}
+}